@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	log "github.com/sirupsen/logrus"
+)
+
+// accountsMenu lets the user switch between their Twitch accounts, add one or merge their followed streams
+type accountsMenu struct {
+	menu     *systray.MenuItem
+	add      *systray.MenuItem
+	merge    *systray.MenuItem
+	items    map[string]*systray.MenuItem // by account namespace
+	switchTo chan string                  // namespace of the clicked account
+}
+
+func newAccountsMenu(accounts *config.Accounts) *accountsMenu {
+	menu := systray.AddMenuItem("Accounts", "Switch Twitch account")
+	m := &accountsMenu{
+		menu:     menu,
+		add:      menu.AddSubMenuItem("Add account…", "Log in to another Twitch account"),
+		merge:    menu.AddSubMenuItemCheckbox("Merge live streams of all accounts", "Display followed live streams of every account", accounts.Merging()),
+		items:    make(map[string]*systray.MenuItem),
+		switchTo: make(chan string, 1),
+	}
+
+	for _, account := range accounts.List() {
+		m.Set(account)
+	}
+
+	m.SetCurrent(accounts.CurrentNamespace())
+	return m
+}
+
+// Set adds or updates the item of given account
+func (m *accountsMenu) Set(account config.Account) {
+	title := accountTitle(account)
+	if item, ok := m.items[account.Namespace]; ok {
+		item.SetTitle(title)
+		item.Show()
+		return
+	}
+
+	item := m.menu.AddSubMenuItemCheckbox(title, "Switch to this account", false)
+	m.items[account.Namespace] = item
+	go func() {
+		for range item.ClickedCh {
+			m.switchTo <- account.Namespace
+		}
+	}()
+}
+
+// Remove hides the item of given account
+func (m *accountsMenu) Remove(namespace string) {
+	if item, ok := m.items[namespace]; ok {
+		item.Hide()
+	}
+}
+
+// SetCurrent checks the item of the current account only
+func (m *accountsMenu) SetCurrent(namespace string) {
+	for n, item := range m.items {
+		if n == namespace {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+}
+
+// SetMerge displays whether followed streams of every account are merged
+func (m *accountsMenu) SetMerge(merge bool) {
+	if merge {
+		m.merge.Check()
+	} else {
+		m.merge.Uncheck()
+	}
+}
+
+// accountTitle returns the name displayed for given account
+func accountTitle(account config.Account) string {
+	switch {
+	case account.DisplayName != "":
+		return account.DisplayName
+	case account.Login != "":
+		return account.Login
+	default:
+		return account.Namespace
+	}
+}
+
+// client returns the Twitch client of the current account
+func (a *Application) client() *twitch.Client {
+	a.twitchMutex.RLock()
+	defer a.twitchMutex.RUnlock()
+	return a.Twitch
+}
+
+// setClient makes given client the one of the current account
+func (a *Application) setClient(namespace string, c *twitch.Client) {
+	a.twitchMutex.Lock()
+	defer a.twitchMutex.Unlock()
+
+	a.Twitch = c
+	a.clients[namespace] = c
+}
+
+// accountClient returns the Twitch client of given account.
+// It is created from the stored session if needed, the user is never asked to log in.
+func (a *Application) accountClient(namespace string) (*twitch.Client, error) {
+	a.twitchMutex.RLock()
+	c, ok := a.clients[namespace]
+	a.twitchMutex.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	c, err := a.newTwitchClient(namespace, true)
+	if err != nil {
+		return nil, err
+	}
+
+	a.twitchMutex.Lock()
+	defer a.twitchMutex.Unlock()
+	if v, ok := a.clients[namespace]; ok {
+		return v, nil // created meanwhile
+	}
+
+	a.clients[namespace] = c
+	return c, nil
+}
+
+// SwitchAccount makes the account of given namespace the current one, logging in if needed, and starts its session routines.
+// The previous account is kept if the user cannot log in. The returned function stops the routines.
+func (a *Application) SwitchAccount(ctx context.Context, out chan<- []*twitch.Stream, invalid chan<- error, menu *sessionMenu, namespace string) context.CancelFunc {
+	menu.LoggingIn()
+	defer a.loginPrompt.Close()
+
+	if err := a.loginAccount(ctx, namespace); err != nil {
+		log.Errorf("unable to switch Twitch account: %s", err)
+	} else {
+		a.accounts.SetCurrent(namespace)
+		a.rememberAccount(menu.accounts)
+	}
+
+	menu.accounts.SetCurrent(a.accounts.CurrentNamespace())
+	me := a.client().Users.Me()
+	if me == nil {
+		menu.LoggedOut()
+		return func() {}
+	}
+
+	menu.LoggedIn(me)
+	return a.StartSession(ctx, out, invalid, menu)
+}
+
+// loginAccount makes the client of given account the current one, logging in if needed
+func (a *Application) loginAccount(ctx context.Context, namespace string) error {
+	a.twitchMutex.RLock()
+	c, ok := a.clients[namespace]
+	a.twitchMutex.RUnlock()
+
+	if !ok {
+		var err error
+		if c, err = a.newTwitchClient(namespace, false); err != nil {
+			return err
+		}
+	} else if c.Users.Me() == nil {
+		if err := c.Login(ctx); err != nil {
+			return err
+		}
+	}
+
+	a.setClient(namespace, c)
+	return nil
+}
+
+// rememberAccount saves the logged in current account and displays it in the accounts menu
+func (a *Application) rememberAccount(menu *accountsMenu) {
+	me := a.client().Users.Me()
+	if me == nil {
+		return
+	}
+
+	account := config.Account{
+		Namespace:   a.accounts.CurrentNamespace(),
+		Login:       me.Login,
+		DisplayName: me.DisplayName,
+	}
+
+	a.accounts.Set(account)
+	if err := a.accounts.Save(); err != nil {
+		log.Errorln(err)
+	}
+
+	menu.Set(account)
+	menu.SetCurrent(account.Namespace)
+}
+
+// forgetAccount removes the current account once logged out
+func (a *Application) forgetAccount(menu *accountsMenu) {
+	namespace := a.accounts.CurrentNamespace()
+	a.accounts.Remove(namespace)
+	if err := a.accounts.Save(); err != nil {
+		log.Errorln(err)
+	}
+
+	menu.Remove(namespace)
+}
+
+// ToggleMerge switches between followed streams of the current account and of every account
+func (a *Application) ToggleMerge(menu *accountsMenu) {
+	merge := !a.accounts.Merging()
+	a.accounts.SetMerge(merge)
+	if err := a.accounts.Save(); err != nil {
+		log.Errorln(err)
+	}
+
+	menu.SetMerge(merge)
+	a.RefreshNow()
+}
+
+// followedStreams returns the followed live streams of the current account,
+// along with the ones of every other account when merging them
+func (a *Application) followedStreams(ctx context.Context) ([]*twitch.Stream, error) {
+	streams, err := a.client().Streams.GetFollowed(ctx)
+	if err != nil || !a.accounts.Merging() {
+		return streams, err
+	}
+
+	current := a.accounts.CurrentNamespace()
+	for _, account := range a.accounts.List() {
+		if account.Namespace == current {
+			continue
+		}
+
+		c, err := a.accountClient(account.Namespace)
+		if err != nil {
+			log.Warningf("unable to use Twitch account %s: %s", accountTitle(account), err)
+			continue
+		}
+
+		other, err := c.Streams.GetFollowed(ctx)
+		if err != nil {
+			log.Warningf("unable to list followed streams of %s: %s", accountTitle(account), err)
+			continue
+		}
+
+		streams = mergeStreams(streams, other)
+	}
+
+	return streams, nil
+}
+
+// mergeStreams appends to streams the ones of other not already in it
+func mergeStreams(streams, other []*twitch.Stream) []*twitch.Stream {
+	seen := make(map[string]bool, len(streams))
+	for _, s := range streams {
+		seen[s.ID] = true
+	}
+
+	for _, s := range other {
+		if !seen[s.ID] {
+			seen[s.ID] = true
+			streams = append(streams, s)
+		}
+	}
+
+	return streams
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/chat"
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxAlertsPerMinute = 3                // alerts notified each minute by default
+	alertChannelCooldown      = time.Second * 30 // minimum delay between two alerts of the same channel
+	maxAlertText              = 200              // longer messages are truncated in notifications
+)
+
+// chatAlerts decides which chat messages are notified
+type chatAlerts struct {
+	me       string           // login of the user, their own messages are not notified
+	patterns []*regexp.Regexp // mention and keywords
+	channels map[string]bool  // only these channels when not empty
+	disabled map[string]bool
+
+	// rate limiting
+	max        int
+	sent       []time.Time          // alerts of the last minute
+	last       map[string]time.Time // last alert by channel
+	suppressed int                  // alerts dropped since the last one notified
+}
+
+// newChatAlerts returns the alerts configured for the user of given login. Invalid keywords are ignored.
+func newChatAlerts(c config.ChatAlerts, me string) *chatAlerts {
+	alerts := &chatAlerts{
+		me:       strings.ToLower(me),
+		channels: make(map[string]bool, len(c.Channels)),
+		disabled: make(map[string]bool, len(c.DisabledChannels)),
+		max:      c.MaxPerMinute,
+		last:     make(map[string]time.Time),
+	}
+
+	if alerts.max <= 0 {
+		alerts.max = defaultMaxAlertsPerMinute
+	}
+
+	if c.Mentions && me != "" {
+		alerts.patterns = append(alerts.patterns, regexp.MustCompile(`(?i)(^|\W)@?`+regexp.QuoteMeta(me)+`\b`))
+	}
+
+	for _, keyword := range c.Keywords {
+		pattern, err := regexp.Compile(keyword)
+		if err != nil {
+			log.Errorf("ignoring invalid chat alert keyword %q: %s", keyword, err)
+			continue
+		}
+
+		alerts.patterns = append(alerts.patterns, pattern)
+	}
+
+	for _, channel := range c.Channels {
+		alerts.channels[strings.ToLower(channel)] = true
+	}
+
+	for _, channel := range c.DisabledChannels {
+		alerts.disabled[strings.ToLower(channel)] = true
+	}
+
+	return alerts
+}
+
+// Channels returns the watched channels among the live ones, sorted
+func (r *chatAlerts) Channels(live map[string]bool) []string {
+	channels := make([]string, 0, len(live))
+	for channel := range live {
+		if r.enabled(channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	sort.Strings(channels)
+	return channels
+}
+
+// enabled returns whether the chat of given channel is watched
+func (r *chatAlerts) enabled(channel string) bool {
+	channel = strings.ToLower(channel)
+	if r.disabled[channel] {
+		return false
+	}
+
+	return len(r.channels) == 0 || r.channels[channel]
+}
+
+// Match returns whether msg is a message of another user matching a rule
+func (r *chatAlerts) Match(msg *chat.Message) bool {
+	if msg.Type != chat.MessageChat || strings.EqualFold(msg.User, r.me) || !r.enabled(msg.Channel) {
+		return false
+	}
+
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(msg.Text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Allow returns whether an alert of given channel can be notified at now, recording it if so.
+// At most max alerts are notified each minute, and one every alertChannelCooldown per channel.
+func (r *chatAlerts) Allow(channel string, now time.Time) bool {
+	recent := r.sent[:0]
+	for _, t := range r.sent {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	r.sent = recent
+
+	if len(r.sent) >= r.max || now.Sub(r.last[channel]) < alertChannelCooldown {
+		r.suppressed++
+		return false
+	}
+
+	r.sent = append(r.sent, now)
+	r.last[channel] = now
+	return true
+}
+
+// WatchChatAlerts reads the chat of the live followed channels and notifies the messages matching the configured rules.
+// Joined channels follow the displayed streams, checked every pollingInterval, on a single connection.
+func (a *Application) WatchChatAlerts(ctx context.Context) {
+	var me string
+	if user := a.client().Users.Me(); user != nil {
+		me = user.Login
+	}
+
+	c, err := a.newChat()
+	if err != nil {
+		log.Errorf("unable to watch chat alerts: %s", err)
+		return
+	}
+
+	alerts := newChatAlerts(a.config.ChatAlerts, me)
+	out := make(chan *chat.Message, 100)
+	ticker := time.NewTicker(pollingInterval)
+	defer ticker.Stop()
+
+	var joined []string
+	listening := false
+	for {
+		channels := alerts.Channels(a.liveLogins())
+		if join, part := difference(channels, joined), difference(joined, channels); len(join) > 0 || len(part) > 0 {
+			log.Debugf("watching chat alerts of %d channels", len(channels))
+			c.Part(part...)
+			c.Join(join...)
+			joined = channels
+		}
+
+		// connect once there is a channel to watch
+		if !listening && len(joined) > 0 {
+			listening = true
+			go func() {
+				if err := c.Listen(ctx, out); ctx.Err() == nil {
+					log.Errorf("unable to watch chat alerts: %s", err)
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugln("received context cancel: WatchChatAlerts")
+			return // returning not to leak the goroutine
+		case <-ticker.C:
+		case msg := <-out:
+			a.alert(alerts, msg, time.Now())
+		}
+	}
+}
+
+// difference returns the elements of a which are not in b
+func difference(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+
+	var diff []string
+	for _, s := range a {
+		if !in[s] {
+			diff = append(diff, s)
+		}
+	}
+
+	return diff
+}
+
+// alert notifies msg if it matches a rule and the rate limit allows it
+func (a *Application) alert(alerts *chatAlerts, msg *chat.Message, now time.Time) {
+	if !alerts.Match(msg) {
+		return
+	}
+
+	if !alerts.Allow(msg.Channel, now) {
+		log.Debugf("chat alert of [%s] rate limited", msg.Channel)
+		return
+	}
+
+	text := msg.Text
+	if len([]rune(text)) > maxAlertText {
+		text = string([]rune(text)[:maxAlertText]) + "…"
+	}
+
+	if alerts.suppressed > 0 {
+		text = fmt.Sprintf("%s (+%d more)", text, alerts.suppressed)
+		alerts.suppressed = 0
+	}
+
+	if err := a.Notifier.Alert(msg.Channel, msg.DisplayName, text, msg.Channel); err != nil {
+		log.Errorf("fail to notify chat alert of [%s]: %s", msg.Channel, err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/chat"
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+)
+
+func Test_chatAlerts_Match(t *testing.T) {
+	alerts := newChatAlerts(config.ChatAlerts{
+		Mentions:         true,
+		Keywords:         []string{`(?i)giveaway`, `[invalid`},
+		DisabledChannels: []string{"Bar"},
+	}, "Me")
+
+	tests := []struct {
+		name string
+		msg  *chat.Message
+		want bool
+	}{
+		{
+			name: "Expected mention matched",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "hello @me!"},
+			want: true,
+		},
+		{
+			name: "Expected mention without @ matched",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "ME what do you think"},
+			want: true,
+		},
+		{
+			name: "Expected login inside a word ignored",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "some memes"},
+		},
+		{
+			name: "Expected keyword matched",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "GiveAway now"},
+			want: true,
+		},
+		{
+			name: "Expected own messages ignored",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "me", Text: "@me"},
+		},
+		{
+			name: "Expected disabled channel ignored",
+			msg:  &chat.Message{Type: chat.MessageChat, Channel: "bar", User: "other", Text: "@me"},
+		},
+		{
+			name: "Expected notices ignored",
+			msg:  &chat.Message{Type: chat.MessageUserNotice, Channel: "foo", User: "other", Text: "@me"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alerts.Match(tt.msg); got != tt.want {
+				t.Errorf("Match() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_chatAlerts_Channels(t *testing.T) {
+	alerts := newChatAlerts(config.ChatAlerts{Channels: []string{"Foo", "bar"}, DisabledChannels: []string{"bar"}}, "me")
+	got := alerts.Channels(map[string]bool{"foo": true, "bar": true, "baz": true})
+	if want := []string{"foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Channels() got = %v, want %v", got, want)
+	}
+}
+
+func Test_chatAlerts_Allow(t *testing.T) {
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		channel string
+		at      time.Duration
+		want    bool
+	}{
+		{name: "Expected first alert allowed", channel: "foo", want: true},
+		{name: "Expected channel cooldown", channel: "foo", at: time.Second * 10},
+		{name: "Expected other channel allowed", channel: "bar", at: time.Second * 10, want: true},
+		{name: "Expected channel allowed after cooldown", channel: "foo", at: time.Second * 40, want: true},
+		{name: "Expected limit per minute", channel: "baz", at: time.Second * 50},
+		{name: "Expected allowed after a minute", channel: "baz", at: time.Second * 61, want: true},
+	}
+
+	alerts := newChatAlerts(config.ChatAlerts{MaxPerMinute: 3}, "me")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alerts.Allow(tt.channel, now.Add(tt.at)); got != tt.want {
+				t.Errorf("Allow() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplication_alert(t *testing.T) {
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	n := &fakeNotifier{}
+	a := &Application{Notifier: n}
+	alerts := newChatAlerts(config.ChatAlerts{Mentions: true}, "me")
+
+	a.alert(alerts, &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "@me hi"}, now)
+	a.alert(alerts, &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "@me again"}, now.Add(time.Second))
+	a.alert(alerts, &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "nothing"}, now.Add(time.Minute))
+	a.alert(alerts, &chat.Message{Type: chat.MessageChat, Channel: "foo", User: "other", Text: "@me later"}, now.Add(time.Minute))
+
+	if want := []string{"foo: @me hi", "foo: @me later (+1 more)"}; !reflect.DeepEqual(n.notified, want) {
+		t.Errorf("alert() notified = %v, want %v", n.notified, want)
+	}
+}
@@ -6,9 +6,9 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SkYNewZ/twitch-clip/internal/config"
@@ -28,6 +28,7 @@ import (
 
 var (
 	// These 2 variables will be overwritten at build time using ldflags
+	// Without client secret, users log in with the Device Code flow
 	twitchClientID     string
 	twitchClientSecret string
 )
@@ -35,6 +36,13 @@ var (
 const (
 	AppName        = "twitchclip"
 	AppDisplayName = "Twitch Clip"
+
+	// passphraseEnv holds the passphrase of the encrypted token store
+	passphraseEnv = "TWITCH_CLIP_PASSPHRASE"
+
+	pollingInterval   = time.Second * 10 // followed streams refresh interval
+	reconcileInterval = time.Minute * 5  // followed streams refresh interval while EventSub watches them all
+	validateInterval  = time.Hour        // Twitch requires apps to validate their session every hour
 )
 
 // Application contains all required dependencies
@@ -45,62 +53,84 @@ type Application struct {
 	// Main cancel function to stop the program
 	Cancel context.CancelFunc
 
-	// Player to use
-	Player player.Player
+	// Player to use, nil until one is found
+	Player     player.Player
+	mediaMutex sync.RWMutex // guards Player and Streamlink
 
-	// Twitch client
-	Twitch *twitch.Client
+	// Twitch client of the current account
+	Twitch      *twitch.Client
+	twitchMutex sync.RWMutex
+	clients     map[string]*twitch.Client // by account namespace, guarded by twitchMutex
+	accounts    *config.Accounts
+	positions   *config.Positions // where videos were stopped
 
+	// Streamlink client, nil until streamlink is found
 	Streamlink streamlink.Client
 
 	Notifier               notifier.Notifier
 	NotificationCallbackCh <-chan string
 
-	// Carry our current displayed items
-	State map[string]*Item
+	// Carry our current displayed items, by itemKey
+	State      map[string]*Item
+	stateMutex sync.RWMutex // guards State, only written by RefreshStreamsMenuItem
+
+	// Category submenus grouping streams, by game ID
+	categories map[string]*categoryMenu
+
+	// Last broadcast of followed channels, by broadcaster ID
+	broadcasts      map[string]*lastBroadcast
+	broadcastsMutex sync.Mutex
+
+	// Currently active streams, from polling and EventSub events
+	activeStreams      []*twitch.Stream
+	activeStreamsMutex sync.Mutex
+	refreshNow         chan struct{}
+	eventSubActive     atomic.Bool // EventSub watches every followed channel
 
 	// Each string in this chan will be send to system clipboard
 	ClipboardListener chan string
 
-	config *config.Config
+	loginPrompt   *deviceCodePrompt // displays the code to enter on Twitch while logging in
+	missingScopes chan []string     // scopes needed by a feature and not granted, offered by the session menu
+	chatView      *chatView         // displays the chat of streams
+	chatURL       string            // Twitch chat server, the default one when empty
+	config        *config.Config
 }
 
 // New creates a new Application
+// Streamlink, the player and Twitch are attached on Setup, once available.
 func New() *Application {
-	// Get media player
-	p, err := player.DefaultPlayer()
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	s, err := streamlink.New()
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	// Get Twitch client
-	twitchClient, err := twitch.New(&twitch.Config{ClientID: twitchClientID, ClientSecret: twitchClientSecret})
-	if err != nil {
-		log.Fatalln(err)
-	}
+	// Load user config
+	c := config.Parse()
 
 	// Start the notifier service
 	n, notificationCh := notifier.New(AppDisplayName)
 
 	// Make the app and inject required dependencies
-	return &Application{
+	a := &Application{
 		Name:                   AppName,
 		DisplayName:            AppDisplayName,
 		Cancel:                 nil,
-		Player:                 p,
-		Twitch:                 twitchClient,
-		Streamlink:             s,
+		Player:                 nil, // set on Setup, once installed
+		Twitch:                 nil, // set on Setup, login may need the tray
+		clients:                make(map[string]*twitch.Client),
+		accounts:               config.LoadAccounts(),
+		positions:              config.LoadPositions(),
+		Streamlink:             nil, // set on Setup, once installed
 		Notifier:               n,
 		NotificationCallbackCh: notificationCh,
 		State:                  make(map[string]*Item),
+		categories:             make(map[string]*categoryMenu),
+		broadcasts:             make(map[string]*lastBroadcast),
+		refreshNow:             make(chan struct{}, 1),
 		ClipboardListener:      make(chan string, 1),
-		config:                 config.Parse(),
+		loginPrompt:            newDeviceCodePrompt(),
+		missingScopes:          make(chan []string, 1),
+		config:                 c,
 	}
+
+	a.chatView = newChatView(a.listenChat)
+	return a
 }
 
 // Setup must not be called before systray.Run or systray.Register
@@ -111,6 +141,9 @@ func (a *Application) Setup() {
 	systray.SetIcon(icon.Data)
 	systray.SetTooltip(a.DisplayName)
 
+	// Display what prevents the app from working, if anything
+	status := newStatusMenu()
+
 	// Manage auto start
 	done := make(chan struct{}, 1)
 	go a.autostart(done)
@@ -135,6 +168,19 @@ func (a *Application) Setup() {
 		}
 	}()
 
+	// Clear cached images, the icons of displayed streams are downloaded again
+	clearCache := systray.AddMenuItem("Clear cache", "Remove cached avatars, box arts and schedules")
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clearCache.ClickedCh:
+				a.ClearCache(ctx)
+			}
+		}
+	}()
+
 	// Display "quit" button and listen for click
 	quit := systray.AddMenuItem("Quit", "Quit the whole app")
 	systray.AddSeparator()
@@ -143,8 +189,72 @@ func (a *Application) Setup() {
 		systray.Quit()
 	}()
 
-	// Start Application
-	a.Start(ctx)
+	go status.HandleClicks(ctx)
+
+	// Find streamlink and a player, they can be installed while running
+	a.AttachMedia(ctx, status)
+
+	// Log in to Twitch, user may have to approve this device from the tray, then start Application
+	go a.AttachTwitch(ctx, status)
+}
+
+// ConnectTwitch creates the Twitch client of the current account, logging in if no session is stored
+func (a *Application) ConnectTwitch() error {
+	defer a.loginPrompt.Close()
+
+	namespace := a.accounts.CurrentNamespace()
+	twitchClient, err := a.newTwitchClient(namespace, false)
+	if err != nil {
+		return err
+	}
+
+	a.setClient(namespace, twitchClient)
+	return nil
+}
+
+// newTwitchClient creates the Twitch client of given account.
+// The user is asked to log in if no session is stored, unless disableLogin is set.
+func (a *Application) newTwitchClient(namespace string, disableLogin bool) (*twitch.Client, error) {
+	return twitch.New(&twitch.Config{
+		ClientID:         twitchClientID,
+		ClientSecret:     twitchClientSecret,
+		MaxStreams:       a.config.MaxStreams,
+		DeviceCode:       a.config.DeviceCodeLogin,
+		DeviceCodePrompt: a.loginPrompt.Display,
+		TokenStore:       a.tokenStore(namespace),
+		Account:          namespace,
+		DisableLogin:     disableLogin,
+		Scopes:           a.chatScopes(),
+		CacheMaxSize:     int64(a.config.CacheMaxSize) << 20,
+		CacheMaxAge:      time.Duration(a.config.CacheMaxDays) * time.Hour * 24,
+	})
+}
+
+// tokenStore returns the configured Twitch session store of given account, nil for the default one
+func (a *Application) tokenStore(namespace string) twitch.TokenStore {
+	switch a.config.TokenStore {
+	case "", config.TokenStoreFile:
+		return nil
+	case config.TokenStoreKeyring:
+		store, err := twitch.NewAccountSecretServiceTokenStore(namespace)
+		if err != nil {
+			log.Errorf("unable to use the keyring, using the token file instead: %s", err)
+			return nil
+		}
+
+		return store
+	case config.TokenStoreEncrypted:
+		passphrase := os.Getenv(passphraseEnv)
+		if passphrase == "" {
+			log.Errorf("missing %s, using the token file instead", passphraseEnv)
+			return nil
+		}
+
+		return twitch.NewAccountEncryptedFileTokenStore(namespace, passphrase)
+	default:
+		log.Errorf("unknown token store %q, using the token file instead", a.config.TokenStore)
+		return nil
+	}
 }
 
 // Start show a Item for each online streams
@@ -157,8 +267,8 @@ func (a *Application) Start(ctx context.Context) {
 	// Listen for notification callback
 	go a.HandleNotificationCallback(ctx)
 
-	// start routines for refreshing streams
-	go a.RefreshActiveStreams(ctx, out)
+	// display connected user, start routines refreshing streams while logged in
+	go a.HandleSession(ctx, out, newSessionMenu(a.accounts))
 
 	// start routine to display these streams
 	go a.RefreshStreamsMenuItem(ctx, out)
@@ -174,6 +284,10 @@ func (a *Application) Stop() {
 	if err := a.Notifier.Close(); err != nil { // notification service
 		log.Errorf("fail to stop notification service: %s", err)
 	}
+
+	if err := a.chatView.Close(); err != nil { // chat view web server
+		log.Errorf("fail to stop chat view: %s", err)
+	}
 }
 
 // autostart make current Application auto start at boot and handle change on the item
@@ -232,40 +346,93 @@ func (a *Application) HandleClipboard(ctx context.Context) {
 	}
 }
 
-// Refresh hide or show menu items based on currently active streams
-func (a *Application) Refresh(activeStreams []*twitch.Stream) {
-	for _, item := range a.State {
+// Refresh hide or show menu items based on currently active streams.
+// Streamers in wasLive are not notified again when their item is shown, they only changed category.
+func (a *Application) Refresh(ctx context.Context, activeStreams []*twitch.Stream, wasLive map[string]bool) {
+	for key, item := range a.State {
 		itemIsAnActiveStream := funk.Contains(activeStreams, func(stream *twitch.Stream) bool {
-			return stream.UserLogin == item.UserLogin
+			return a.itemKey(stream) == key
 		})
 
-		item.SetVisible(itemIsAnActiveStream)
+		item.SetVisible(ctx, itemIsAnActiveStream, !wasLive[item.UserLogin])
 	}
+
+	a.RefreshCategories()
 }
 
-func (a *Application) DisplayConnectedUser() {
-	me := a.Twitch.Users.Me()
-	title := fmt.Sprintf("Connected as %s", me.DisplayName)
-	systray.AddMenuItem(title, "Current user").Disable()
+// ClearCache clears the cache of every Twitch account and sets the icons of displayed streams again
+func (a *Application) ClearCache(ctx context.Context) {
+	a.twitchMutex.RLock()
+	clients := make([]*twitch.Client, 0, len(a.clients))
+	for _, c := range a.clients {
+		clients = append(clients, c)
+	}
+	a.twitchMutex.RUnlock()
+
+	for _, c := range clients {
+		if err := c.ClearCache(); err != nil {
+			log.Errorf("unable to clear cache: %s", err)
+		}
+	}
+
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+	for _, item := range a.State {
+		if item.IsVisible() {
+			go item.SetIcon(ctx)
+		}
+	}
+}
+
+// liveLogins returns the login of each streamer currently displayed
+func (a *Application) liveLogins() map[string]bool {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	logins := make(map[string]bool, len(a.State))
+	for _, item := range a.State {
+		if item.IsVisible() {
+			logins[item.UserLogin] = true
+		}
+	}
+
+	return logins
+}
+
+// itemByLogin returns the displayed menu Item of given streamer
+func (a *Application) itemByLogin(login string) (*Item, bool) {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+
+	for _, item := range a.State {
+		if item.UserLogin == login && item.IsVisible() {
+			return item, true
+		}
+	}
+
+	return nil, false
 }
 
 // RefreshActiveStreams send active streams to out
+// Streams are polled every pollingInterval, or every reconcileInterval while EventSub watches every followed channel.
+// Followed streams of every account are merged when configured.
 func (a *Application) RefreshActiveStreams(ctx context.Context, out chan<- []*twitch.Stream) {
-	ticker := time.NewTicker(time.Second * 10)
-	defer ticker.Stop()
-
 	job := func() {
 		log.Debugln("refreshing followed streams infos")
 
 		// This simulates /streams/followed endpoint
-		streams, err := a.Twitch.Streams.GetFollowed()
+		streams, err := a.followedStreams(ctx)
 		if err != nil {
 			log.Errorf("unable to list followed streams: %s", err)
 			return
 		}
 
+		a.classifyStreams(ctx, streams)
+
 		// job done, notify out for the new stream list
-		out <- streams
+		a.PublishActiveStreams(ctx, out, func([]*twitch.Stream) []*twitch.Stream {
+			return streams
+		})
 	}
 
 	// https://stackoverflow.com/a/54752803
@@ -276,12 +443,63 @@ func (a *Application) RefreshActiveStreams(ctx context.Context, out chan<- []*tw
 		case <-ctx.Done():
 			log.Debugln("received context cancel: RefreshActiveStreams")
 			return // returning not to leak the goroutine
-		case <-ticker.C:
+		case <-a.refreshNow:
+			continue
+		case <-time.After(a.refreshInterval()):
 			continue
 		}
 	}
 }
 
+// RefreshNow asks RefreshActiveStreams to poll followed streams without waiting for the next interval
+func (a *Application) RefreshNow() {
+	select {
+	case a.refreshNow <- struct{}{}:
+	default: // a refresh is already pending
+	}
+}
+
+// refreshInterval returns the time to wait between two followed streams polls.
+// EventSub only watches the channels followed by the current account.
+func (a *Application) refreshInterval() time.Duration {
+	if a.eventSubActive.Load() && !a.accounts.Merging() {
+		return reconcileInterval
+	}
+
+	return pollingInterval
+}
+
+// PublishActiveStreams replaces active streams by the result of update and sends them to out.
+// Updates are serialized so out never receives an outdated list after a newer one.
+func (a *Application) PublishActiveStreams(ctx context.Context, out chan<- []*twitch.Stream, update func([]*twitch.Stream) []*twitch.Stream) {
+	a.activeStreamsMutex.Lock()
+	defer a.activeStreamsMutex.Unlock()
+
+	// routines of a previous session must not publish anymore
+	if ctx.Err() != nil {
+		return
+	}
+
+	a.activeStreams = update(a.activeStreams)
+	streams := make([]*twitch.Stream, len(a.activeStreams))
+	copy(streams, a.activeStreams)
+
+	select {
+	case <-ctx.Done():
+	case out <- streams:
+	}
+}
+
+// ActiveStreams returns a copy of the currently active streams
+func (a *Application) ActiveStreams() []*twitch.Stream {
+	a.activeStreamsMutex.Lock()
+	defer a.activeStreamsMutex.Unlock()
+
+	streams := make([]*twitch.Stream, len(a.activeStreams))
+	copy(streams, a.activeStreams)
+	return streams
+}
+
 // RefreshStreamsMenuItem display a menu Item for each stream received in the channel in
 func (a *Application) RefreshStreamsMenuItem(ctx context.Context, in <-chan []*twitch.Stream) {
 	// not active stream menu Item
@@ -294,37 +512,52 @@ func (a *Application) RefreshStreamsMenuItem(ctx context.Context, in <-chan []*t
 	}
 	menuNoActiveStreams.Disable()
 
-	// display connected user
-	a.DisplayConnectedUser()
-
 	for {
 		select {
 		case <-ctx.Done():
 			log.Debugln("received context cancel: RefreshStreamsMenuItem")
 			return // returning not to leak the goroutine
 		case activeStreams := <-in:
+			activeStreams = a.visibleStreams(activeStreams)
 			log.Debugf("refreshing menu items for %d active followed streams", len(activeStreams))
-			menuNoActiveStreams.SetVisible(len(activeStreams) == 0)
+			menuNoActiveStreams.SetVisible(ctx, len(activeStreams) == 0, false)
 
+			wasLive := a.liveLogins()
+			var created []*categoryMenu
 			for _, s := range activeStreams {
 				// stream already in the stream list. Refresh title and tooltip and show it
-				if v, ok := a.State[s.UserLogin]; ok {
+				key := a.itemKey(s)
+				if v, ok := a.State[key]; ok {
 					v.Refresh(s)
 					continue
 				}
 
+				category, isNew := a.category(s)
+				if isNew {
+					created = append(created, category)
+				}
+
 				// stream not already in the stream list, make it!
-				a.State[s.UserLogin] = a.NewItem(ctx, s)
+				item := a.NewItem(ctx, s, category, !wasLive[s.UserLogin])
+				a.stateMutex.Lock()
+				a.State[key] = item
+				a.stateMutex.Unlock()
+			}
+
+			// new categories get their box art
+			if len(created) > 0 {
+				go a.SetBoxArts(ctx, created)
 			}
 
 			// refresh app
-			a.Refresh(activeStreams)
+			a.Refresh(ctx, activeStreams, wasLive)
 		}
 	}
 }
 
-// NewItem creates a new menu Item and its underlying routines
-func (a *Application) NewItem(ctx context.Context, s *twitch.Stream) *Item {
+// NewItem creates a new menu Item and its underlying routines.
+// The item is added to category, if any. notify is false when the streamer was already live in another category.
+func (a *Application) NewItem(ctx context.Context, s *twitch.Stream, category *categoryMenu, notify bool) *Item {
 	log.WithFields(map[string]interface{}{
 		"login":      s.UserLogin,
 		"user_login": s.UserName,
@@ -340,25 +573,42 @@ func (a *Application) NewItem(ctx context.Context, s *twitch.Stream) *Item {
 
 	item := &Item{
 		Application: a,
-		Item:        systray.AddMenuItem(fmt.Sprintf("%s (%s)", username, s.GameName), s.Title),
 		Visible:     true, // Visible by default
+		UserID:      s.UserID,
 		UserLogin:   s.UserLogin,
-		Username:    s.UserName,
+		Username:    username,
 		Game:        s.GameName,
+		stream:      s,
+		category:    category,
 		mutex:       sync.Mutex{},
 	}
 
+	if category != nil {
+		item.Item = category.item.AddSubMenuItem(item.title(), s.Title)
+	} else {
+		item.Item = systray.AddMenuItem(item.title(), s.Title)
+	}
+
+	item.live = item.Item.AddSubMenuItem("Watch live", s.Title)
+	item.chat = item.Item.AddSubMenuItem("Open chat", "Open the chat in its own window")
+	item.clips = newClipsMenu(item.Item)
+	item.videos = a.newVideosMenu(item.Item)
+
 	// Start routine to pull its icon
-	go item.SetIcon()
+	go item.SetIcon(ctx)
 
 	// Start routine click for this Item
 	go item.Click(ctx)
 
+	// Start routine listing its recent clips
+	go item.RefreshClips(ctx)
+
+	// Start routine listing its latest videos
+	go item.RefreshVideos(ctx)
+
 	// New item appear, so notify if configured
-	if item.ShouldNotify() {
-		if err := a.Notifier.Notify(username, item.Game, item.UserLogin); err != nil {
-			log.Errorf("fail to notify for [%s]: %s", item.UserLogin, err)
-		}
+	if notify && item.ShouldNotify() {
+		go a.NotifyLive(ctx, s)
 	}
 
 	return item
@@ -373,9 +623,14 @@ func (a *Application) HandleNotificationCallback(ctx context.Context) {
 			return // returning not to leak the goroutine
 		case v := <-a.NotificationCallbackCh:
 			// get menu item matching streamer name
-			item, ok := a.State[v]
+			item, ok := a.itemByLogin(v)
 			if !ok {
-				log.Errorf("received notification callback for non-existent stream [%s]", v)
+				// reminders are sent before streams start
+				log.Debugf("received notification callback for offline stream [%s], opening channel", v)
+				if err := browser.OpenURL("https://www.twitch.tv/" + v); err != nil {
+					log.Errorf("unable to open channel [%s]: %s", v, err)
+				}
+
 				continue
 			}
 
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+// newTestApplication returns an Application connected to given fake Helix server
+func newTestApplication(t *testing.T, srv *twitchtest.Server) *Application {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	c, err := twitch.New(&twitch.Config{
+		ClientID:    twitchtest.ClientID,
+		BaseURL:     srv.URL,
+		HTTPClient:  srv.Client(),
+		EventSubURL: srv.EventSubURL(),
+	})
+	if err != nil {
+		t.Fatalf("twitch.New() error = %v", err)
+	}
+
+	return &Application{
+		Name:              AppName,
+		DisplayName:       AppDisplayName,
+		Twitch:            c,
+		clients:           map[string]*twitch.Client{"": c},
+		accounts:          &config.Accounts{},
+		State:             make(map[string]*Item),
+		categories:        make(map[string]*categoryMenu),
+		broadcasts:        make(map[string]*lastBroadcast),
+		refreshNow:        make(chan struct{}, 1),
+		ClipboardListener: make(chan string, 1),
+		config:            &config.Config{},
+	}
+}
+
+func TestApplication_RefreshActiveStreams(t *testing.T) {
+	tests := []struct {
+		name    string
+		streams []*twitchtest.Stream
+		follows []string
+		fail    int
+		want    []string // logins, nil when nothing must be sent
+	}{
+		{
+			name: "Expected followed streams only",
+			streams: []*twitchtest.Stream{
+				{ID: "s2", UserID: "2", UserLogin: "bar"},
+				{ID: "s3", UserID: "3", UserLogin: "baz"},
+			},
+			follows: []string{"2"},
+			want:    []string{"bar"},
+		},
+		{
+			name:    "Expected empty list",
+			streams: nil,
+			follows: []string{"2"},
+			want:    []string{},
+		},
+		{
+			name:    "Expected nothing sent on error",
+			streams: []*twitchtest.Stream{{ID: "s2", UserID: "2", UserLogin: "bar"}},
+			follows: []string{"2"},
+			fail:    http.StatusInternalServerError,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := twitchtest.NewServer()
+			defer srv.Close()
+
+			srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"})
+			srv.AddStream(tt.streams...)
+			srv.Follow(tt.follows...)
+			srv.Fail("/streams/followed", tt.fail, "foo")
+
+			a := newTestApplication(t, srv)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out := make(chan []*twitch.Stream, 1)
+			go a.RefreshActiveStreams(ctx, out)
+
+			select {
+			case streams := <-out:
+				got := make([]string, 0, len(streams))
+				for _, s := range streams {
+					got = append(got, s.UserLogin)
+				}
+
+				if tt.want == nil || !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("RefreshActiveStreams() got = %v, want %v", got, tt.want)
+				}
+			case <-time.After(time.Second):
+				if tt.want != nil {
+					t.Errorf("RefreshActiveStreams() sent nothing, want %v", tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_followedStreams(t *testing.T) {
+	tests := []struct {
+		name  string
+		merge bool
+		fail  int // status of the other account
+		want  []string
+	}{
+		{
+			name: "Expected current account only",
+			want: []string{"bar"},
+		},
+		{
+			name:  "Expected streams of every account without duplicate",
+			merge: true,
+			want:  []string{"bar", "baz"},
+		},
+		{
+			name:  "Expected other accounts skipped on error",
+			merge: true,
+			fail:  http.StatusBadRequest,
+			want:  []string{"bar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := twitchtest.NewServer()
+			defer srv.Close()
+			srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"})
+			srv.AddStream(&twitchtest.Stream{ID: "s2", UserID: "2", UserLogin: "bar"})
+			srv.Follow("2")
+
+			other := twitchtest.NewServer()
+			defer other.Close()
+			other.AddUser(&twitchtest.User{ID: "10", Login: "alt"})
+			other.AddStream(
+				&twitchtest.Stream{ID: "s2", UserID: "2", UserLogin: "bar"},
+				&twitchtest.Stream{ID: "s3", UserID: "3", UserLogin: "baz"},
+			)
+			other.Follow("2", "3")
+
+			a := newTestApplication(t, srv)
+			c, err := twitch.New(&twitch.Config{
+				ClientID:   twitchtest.ClientID,
+				BaseURL:    other.URL,
+				HTTPClient: other.Client(),
+				Account:    "account-1",
+			})
+			if err != nil {
+				t.Fatalf("twitch.New() error = %v", err)
+			}
+			other.Fail("/streams/followed", tt.fail, "foo")
+
+			a.clients["account-1"] = c
+			a.accounts.Merge = tt.merge
+			a.accounts.Set(config.Account{Namespace: "", Login: "foo"})
+			a.accounts.Set(config.Account{Namespace: "account-1", Login: "alt"})
+
+			streams, err := a.followedStreams(context.Background())
+			if err != nil {
+				t.Fatalf("followedStreams() error = %v", err)
+			}
+
+			got := make([]string, 0, len(streams))
+			for _, s := range streams {
+				got = append(got, s.UserLogin)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("followedStreams() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplication_NotifyLive(t *testing.T) {
+	tests := []struct {
+		name          string
+		previews      bool   // notifier displays previews
+		thumbnail     string // served when set, the default preview otherwise
+		wantThumbnail bool
+	}{
+		{
+			name:          "Expected avatar and preview attached",
+			previews:      true,
+			wantThumbnail: true,
+		},
+		{
+			name:      "Expected notification without preview when unavailable",
+			previews:  true,
+			thumbnail: "/previews/unknown.svg",
+		},
+		{
+			name: "Expected preview not downloaded when not displayed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := twitchtest.NewServer()
+			defer srv.Close()
+
+			srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"}, &twitchtest.User{ID: "2", Login: "bar"})
+			stream := &twitch.Stream{UserID: "2", UserLogin: "bar", GameName: "Just Chatting", Title: "hello"}
+			stream.ThumbnailURL = srv.URL + "/previews/live_user_bar-{width}x{height}.jpg"
+			if tt.thumbnail != "" {
+				stream.ThumbnailURL = srv.URL + tt.thumbnail
+			}
+
+			n := &fakeNotifier{previews: tt.previews}
+			a := newTestApplication(t, srv)
+			a.Notifier = n
+			a.NotifyLive(context.Background(), stream)
+
+			if len(n.streams) != 1 {
+				t.Fatalf("NotifyLive() notified = %v, want 1 notification", n.notified)
+			}
+
+			got := n.streams[0]
+			if got.ID != "bar" || got.Username != "bar" || got.Title != "hello" {
+				t.Errorf("NotifyLive() got = %+v, want stream of bar", got)
+			}
+			if _, err := os.Stat(got.Avatar); err != nil {
+				t.Errorf("NotifyLive() avatar error = %v", err)
+			}
+			if _, err := os.Stat(got.Thumbnail); (err == nil) != tt.wantThumbnail {
+				t.Errorf("NotifyLive() thumbnail = %q, want %v", got.Thumbnail, tt.wantThumbnail)
+			}
+			if !tt.previews && srv.Requests("/previews/live_user_bar-440x248.jpg") > 0 {
+				t.Errorf("NotifyLive() downloaded a preview which is not displayed")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	log "github.com/sirupsen/logrus"
+)
+
+// categoryMenu groups the live streams of a category
+type categoryMenu struct {
+	item   *systray.MenuItem
+	gameID string
+}
+
+// itemKey identifies the menu Item of given stream.
+// Grouped by category, a stream moves to another Item when its category changes.
+func (a *Application) itemKey(s *twitch.Stream) string {
+	if !a.config.GroupByCategory || s.GameID == "" {
+		return s.UserLogin
+	}
+
+	return s.GameID + "/" + s.UserLogin
+}
+
+// category returns the submenu of the category of given stream and whether it has just been created.
+// It returns nil when streams are not grouped or the stream has no category.
+func (a *Application) category(s *twitch.Stream) (*categoryMenu, bool) {
+	if !a.config.GroupByCategory || s.GameID == "" {
+		return nil, false
+	}
+
+	if c, ok := a.categories[s.GameID]; ok {
+		return c, false
+	}
+
+	c := &categoryMenu{
+		item:   systray.AddMenuItem(s.GameName, s.GameName),
+		gameID: s.GameID,
+	}
+
+	a.categories[s.GameID] = c
+	return c, true
+}
+
+// RefreshCategories shows categories having at least one visible stream
+func (a *Application) RefreshCategories() {
+	visible := make(map[string]bool, len(a.categories))
+	for _, item := range a.State {
+		if item.category != nil && item.IsVisible() {
+			visible[item.category.gameID] = true
+		}
+	}
+
+	for id, c := range a.categories {
+		if visible[id] {
+			c.item.Show()
+		} else {
+			c.item.Hide()
+		}
+	}
+}
+
+// SetBoxArts sets the box art of each given category as its icon, games are requested at once
+func (a *Application) SetBoxArts(ctx context.Context, categories []*categoryMenu) {
+	ids := make([]string, 0, len(categories))
+	byID := make(map[string]*categoryMenu, len(categories))
+	for _, c := range categories {
+		ids = append(ids, c.gameID)
+		byID[c.gameID] = c
+	}
+
+	games, err := a.client().Games.Get(ctx, ids...)
+	if err != nil {
+		log.Errorf("unable to get categories: %s", err)
+		return
+	}
+
+	for _, game := range games {
+		c, ok := byID[game.ID]
+		if !ok {
+			continue
+		}
+
+		img, err := a.client().Games.BoxArtBytes(ctx, game)
+		if err != nil {
+			log.Errorf("unable to get box art of %s: %s", game.Name, err)
+			continue
+		}
+
+		c.item.SetIcon(img)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+)
+
+func TestApplication_itemKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		grouped bool
+		stream  *twitch.Stream
+		want    string
+	}{
+		{
+			name:   "Expected login when not grouped",
+			stream: &twitch.Stream{UserLogin: "foo", GameID: "1"},
+			want:   "foo",
+		},
+		{
+			name:    "Expected category and login when grouped",
+			grouped: true,
+			stream:  &twitch.Stream{UserLogin: "foo", GameID: "1"},
+			want:    "1/foo",
+		},
+		{
+			name:    "Expected login without category",
+			grouped: true,
+			stream:  &twitch.Stream{UserLogin: "foo"},
+			want:    "foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Application{config: &config.Config{GroupByCategory: tt.grouped}}
+			if got := a.itemKey(tt.stream); got != tt.want {
+				t.Errorf("itemKey() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
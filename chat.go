@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+
+	"github.com/SkYNewZ/twitch-clip/internal/chat"
+	"github.com/pkg/browser"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	chatPath       = "/chat"
+	chatEventsPath = "/chat/events"
+	chatScope      = "chat:read"
+)
+
+// channelPattern matches Twitch logins
+var channelPattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,25}$`)
+
+// chatView serves the chat of streams as a local web page, opened in its own window when possible
+type chatView struct {
+	listen func(ctx context.Context, channels []string, out chan<- *chat.Message) error // reads chats until ctx is done
+
+	mutex  sync.Mutex
+	server *http.Server // started on first use
+	addr   string
+}
+
+// chatEvent is a chat message sent to the page
+type chatEvent struct {
+	Type   chat.MessageType `json:"type"`
+	ID     string           `json:"id,omitempty"`
+	User   string           `json:"user,omitempty"`
+	Name   string           `json:"name,omitempty"`
+	Color  string           `json:"color,omitempty"`
+	Text   string           `json:"text,omitempty"`
+	Action bool             `json:"action,omitempty"`
+}
+
+func newChatView(listen func(context.Context, []string, chan<- *chat.Message) error) *chatView {
+	return &chatView{listen: listen}
+}
+
+// Open displays the chat of given channel
+func (v *chatView) Open(channel string) error {
+	if !channelPattern.MatchString(channel) {
+		return fmt.Errorf("invalid chat channel %q", channel)
+	}
+
+	addr, err := v.start()
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("http://%s%s?channel=%s", addr, chatPath, url.QueryEscape(channel))
+	log.Debugf("opening chat of [%s] at %s", channel, u)
+	return openWindow(u)
+}
+
+// Close stops the web server, if started
+func (v *chatView) Close() error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.server == nil {
+		return nil
+	}
+
+	return v.server.Close()
+}
+
+// start starts the web server on a free local port, once
+func (v *chatView) start() (string, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.server != nil {
+		return v.addr, nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("unable to start chat view: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(chatPath, v.handlePage)
+	mux.HandleFunc(chatEventsPath, v.handleEvents)
+
+	v.server = &http.Server{Handler: mux}
+	v.addr = listener.Addr().String()
+	go func() {
+		log.Debugf("starting chat view web server at %s", v.addr)
+		if err := v.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("chat view web server failed: %s", err)
+		}
+	}()
+
+	return v.addr, nil
+}
+
+func (v *chatView) handlePage(w http.ResponseWriter, r *http.Request) {
+	channel := r.URL.Query().Get("channel")
+	if !channelPattern.MatchString(channel) {
+		http.Error(w, "invalid channel", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := chatPage.Execute(w, channel); err != nil {
+		log.Errorf("unable to render chat page: %s", err)
+	}
+}
+
+// handleEvents streams the chat messages of a channel as server-sent events, until the page is closed
+func (v *chatView) handleEvents(w http.ResponseWriter, r *http.Request) {
+	channel := r.URL.Query().Get("channel")
+	flusher, ok := w.(http.Flusher)
+	if !ok || !channelPattern.MatchString(channel) {
+		http.Error(w, "invalid channel", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	ctx := r.Context()
+	out := make(chan *chat.Message, 100)
+	go func() {
+		if err := v.listen(ctx, []string{channel}, out); ctx.Err() == nil {
+			log.Errorf("unable to read chat of [%s]: %s", channel, err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("chat view of [%s] closed", channel)
+			return
+		case msg := <-out:
+			data, err := json.Marshal(&chatEvent{
+				Type:   msg.Type,
+				ID:     msg.ID,
+				User:   msg.User,
+				Name:   msg.DisplayName,
+				Color:  msg.Color,
+				Text:   msg.Text,
+				Action: msg.Action,
+			})
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// openWindow opens u in a standalone browser window if a Chromium based browser is found, in a browser tab otherwise
+func openWindow(u string) error {
+	for _, command := range appWindowCommands() {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+
+		args := append(command[1:], "--app="+u)
+		if err := exec.Command(path, args...).Start(); err == nil {
+			return nil
+		}
+	}
+
+	return browser.OpenURL(u)
+}
+
+// appWindowCommands returns the commands of browsers able to open a page in its own window, before the --app flag
+func appWindowCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		var commands [][]string
+		for _, app := range []string{"Google Chrome", "Microsoft Edge", "Chromium", "Brave Browser"} {
+			if _, err := os.Stat(filepath.Join("/Applications", app+".app")); err == nil {
+				commands = append(commands, []string{"open", "-na", app, "--args"})
+			}
+		}
+
+		return commands
+	case "windows":
+		commands := [][]string{{"msedge"}, {"chrome"}}
+		for _, dir := range []string{os.Getenv("ProgramFiles(x86)"), os.Getenv("ProgramFiles")} {
+			if dir != "" {
+				commands = append(commands, []string{filepath.Join(dir, "Microsoft", "Edge", "Application", "msedge.exe")})
+			}
+		}
+
+		return commands
+	default:
+		return [][]string{{"chromium"}, {"chromium-browser"}, {"google-chrome"}, {"google-chrome-stable"}, {"microsoft-edge"}, {"brave-browser"}}
+	}
+}
+
+// chatPage displays the chat of the channel given as data
+var chatPage = template.Must(template.New("chat").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.}} – chat</title>
+<style>
+	body { margin: 0; background: #18181b; color: #efeff1; font: 14px/1.5 sans-serif; }
+	#messages { padding: 8px; overflow-wrap: anywhere; }
+	.message { padding: 2px 0; }
+	.name { font-weight: bold; }
+	.notice { color: #adadb8; font-style: italic; }
+	.deleted { text-decoration: line-through; color: #adadb8; }
+</style>
+</head>
+<body>
+<div id="messages"></div>
+<script>
+	const maxMessages = 500;
+	const messages = document.getElementById("messages");
+	const events = new EventSource("/chat/events?channel=" + encodeURIComponent("{{.}}"));
+
+	function add(className, name, color, text, id, user) {
+		const div = document.createElement("div");
+		div.className = "message " + className;
+		if (id) div.dataset.id = id;
+		if (user) div.dataset.user = user;
+		if (name) {
+			const span = document.createElement("span");
+			span.className = "name";
+			span.style.color = color || "#a970ff";
+			span.textContent = name + (className === "action" ? " " : ": ");
+			div.appendChild(span);
+		}
+		div.appendChild(document.createTextNode(text));
+
+		const bottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 10;
+		messages.appendChild(div);
+		while (messages.children.length > maxMessages) messages.firstChild.remove();
+		if (bottom) window.scrollTo(0, document.body.scrollHeight);
+	}
+
+	events.onmessage = (e) => {
+		const msg = JSON.parse(e.data);
+		switch (msg.type) {
+		case "connected":
+			add("notice", "", "", "Connected to the chat of {{.}}");
+			break;
+		case "PRIVMSG":
+			add(msg.action ? "action" : "", msg.name, msg.color, msg.text, msg.id, msg.user);
+			break;
+		case "USERNOTICE":
+		case "NOTICE":
+			add("notice", "", "", msg.text);
+			break;
+		case "CLEARCHAT":
+			document.querySelectorAll(msg.user ? ".message[data-user='" + CSS.escape(msg.user) + "']" : ".message[data-user]")
+				.forEach((div) => div.classList.add("deleted"));
+			break;
+		case "CLEARMSG":
+			document.querySelectorAll(".message[data-id='" + CSS.escape(msg.id) + "']")
+				.forEach((div) => div.classList.add("deleted"));
+			break;
+		}
+	};
+</script>
+</body>
+</html>
+`))
+
+// newChat returns a chat client reading as the current user when configured.
+// The chat is read anonymously if Twitch refuses the user session or it was not granted chat:read.
+func (a *Application) newChat() (*chat.Client, error) {
+	config := &chat.Config{URL: a.chatURL, FallbackAnonymous: true}
+	client := a.client()
+	if a.config.ChatLogin && client != nil && client.Users.Me() != nil {
+		if err := client.RequireScopes(chatScope); err != nil {
+			log.Warningf("%s, reading chat anonymously", err)
+			a.requestScopes(err)
+		} else {
+			config.Login, config.Token = client.Users.Me().Login, client.AccessToken
+		}
+	}
+
+	return chat.New(config)
+}
+
+// listenChat reads the chat of given channels until ctx is done, see newChat
+func (a *Application) listenChat(ctx context.Context, channels []string, out chan<- *chat.Message) error {
+	c, err := a.newChat()
+	if err != nil {
+		return err
+	}
+
+	c.Join(channels...)
+	return c.Listen(ctx, out)
+}
+
+// chatScopes returns the additional scopes to request on login
+func (a *Application) chatScopes() []string {
+	if a.config.ChatLogin {
+		return []string{chatScope}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/chat"
+	"github.com/SkYNewZ/twitch-clip/internal/chat/chattest"
+)
+
+func Test_chatView_handleEvents(t *testing.T) {
+	irc := chattest.NewServer()
+	defer irc.Close()
+
+	v := newChatView(func(ctx context.Context, channels []string, out chan<- *chat.Message) error {
+		c, _ := chat.New(&chat.Config{URL: irc.WebSocketURL()})
+		c.Join(channels...)
+		return c.Listen(ctx, out)
+	})
+	srv := httptest.NewServer(http.HandlerFunc(v.handleEvents))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		channel string
+		status  int
+		want    []string // events data
+	}{
+		{
+			name:    "Expected chat messages",
+			channel: "bar",
+			status:  http.StatusOK,
+			want: []string{
+				`{"type":"connected"}`,
+				`{"type":"PRIVMSG","id":"abc","user":"foo","name":"Foo","color":"#1E90FF","text":"hello \u003cb\u003e"}`,
+			},
+		},
+		{
+			name:    "Expected invalid channel",
+			channel: "bar/../baz",
+			status:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "?channel=" + tt.channel)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("handleEvents() status = %v, want %v", resp.StatusCode, tt.status)
+			}
+
+			if len(tt.want) == 0 {
+				return
+			}
+
+			if !irc.WaitJoined(time.Second, tt.channel) {
+				t.Fatalf("handleEvents() channel not joined")
+			}
+			irc.Send(tt.channel, `@color=#1E90FF;display-name=Foo;id=abc :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hello <b>`)
+
+			scanner := bufio.NewScanner(resp.Body)
+			var got []string
+			for len(got) < len(tt.want) && scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					got = append(got, data)
+				}
+			}
+
+			for i := range tt.want {
+				if i >= len(got) || got[i] != tt.want[i] {
+					t.Errorf("handleEvents() got = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
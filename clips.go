@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	clipsWindow          = time.Hour * 24 * 7 // age of the clips listed
+	clipsRefreshInterval = time.Minute * 30   // recent clips refresh interval
+	maxClips             = 10                 // number of clips listed per streamer
+)
+
+// newClipsMenu lists the most viewed recent clips of a streamer
+func newClipsMenu(parent *systray.MenuItem) *mediaMenu[*twitch.Clip] {
+	menu := parent.AddSubMenuItem("Top clips (7 days)", "Most viewed clips of the last 7 days")
+	return newMediaMenu(menu, "No clip", describeClip)
+}
+
+// describeClip returns the displayed title and tooltip of given clip
+func describeClip(clip *twitch.Clip) (string, string) {
+	return fmt.Sprintf("%s (%d views)", clip.Title, clip.ViewCount), fmt.Sprintf("Clipped by %s", clip.CreatorName)
+}
+
+// RefreshClips lists the most viewed clips of the last 7 days every clipsRefreshInterval, while the Item is visible
+func (i *Item) RefreshClips(ctx context.Context) {
+	for {
+		if i.IsVisible() {
+			i.SetClips(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugf("received context cancel: RefreshClips [%s]", i.UserLogin)
+			return // returning not to leak the goroutine
+		case <-time.After(clipsRefreshInterval):
+		}
+	}
+}
+
+// SetClips lists the most viewed clips of the last 7 days
+func (i *Item) SetClips(ctx context.Context) {
+	clips, err := i.Application.RecentClips(ctx, i.UserID)
+	if err != nil {
+		log.Errorf("unable to list clips of %s: %s", i.UserLogin, err)
+		return
+	}
+
+	i.clips.Set(clips, func(clip *twitch.Clip) {
+		log.Debugf("[%s] clip %s is clicked", i.UserLogin, clip.ID)
+		if err := i.Application.Play(clip.URL, clip.Title); err != nil {
+			log.Errorln(err)
+		}
+	})
+}
+
+// RecentClips returns the most viewed clips of the last 7 days of given broadcaster
+func (a *Application) RecentClips(ctx context.Context, broadcasterID string) ([]*twitch.Clip, error) {
+	now := time.Now()
+	return a.client().Clips.GetByBroadcaster(ctx, broadcasterID, now.Add(-clipsWindow), now, maxClips)
+}
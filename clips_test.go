@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+// fakeStreamlink resolves pages to "<page>/stream"
+type fakeStreamlink struct{ err error }
+
+func (s *fakeStreamlink) Run(streamer string) ([]byte, error) {
+	return s.RunURL("https://www.twitch.tv/" + streamer)
+}
+
+func (s *fakeStreamlink) RunURL(u string) ([]byte, error) {
+	return []byte(u + "/stream\n"), s.err
+}
+
+// fakePlayer records played URLs and their start position
+type fakePlayer struct {
+	played []string
+	starts []time.Duration
+}
+
+func (p *fakePlayer) Name() string { return "fake" }
+
+func (p *fakePlayer) Run(u, title string, output io.Writer) error {
+	return p.RunAt(u, title, 0, output)
+}
+
+func (p *fakePlayer) RunAt(u, _ string, start time.Duration, _ io.Writer) error {
+	p.played = append(p.played, u)
+	p.starts = append(p.starts, start)
+	return nil
+}
+
+func TestApplication_RecentClips(t *testing.T) {
+	srv := twitchtest.NewServer()
+	defer srv.Close()
+
+	now := time.Now()
+	srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"})
+	srv.AddClip(
+		&twitchtest.Clip{ID: "c1", BroadcasterID: "2", CreatedAt: now.Add(-time.Hour)},
+		&twitchtest.Clip{ID: "c2", BroadcasterID: "2", CreatedAt: now.Add(-time.Hour * 24 * 8)},
+		&twitchtest.Clip{ID: "c3", BroadcasterID: "2", CreatedAt: now.Add(-time.Hour * 24 * 6)},
+		&twitchtest.Clip{ID: "c4", BroadcasterID: "3", CreatedAt: now.Add(-time.Hour)},
+	)
+
+	a := newTestApplication(t, srv)
+	clips, err := a.RecentClips(context.Background(), "2")
+	if err != nil {
+		t.Fatalf("RecentClips() error = %v", err)
+	}
+
+	got := make([]string, 0, len(clips))
+	for _, c := range clips {
+		got = append(got, c.ID)
+	}
+	if want := []string{"c1", "c3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecentClips() got = %v, want %v", got, want)
+	}
+}
+
+func TestApplication_Play(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Expected stream URL played and copied",
+			want: []string{"https://clips.twitch.tv/foo/stream"},
+		},
+		{
+			name:    "Expected error when streamlink fails",
+			err:     errors.New("foo"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakePlayer{}
+			a := &Application{
+				Streamlink:        &fakeStreamlink{err: tt.err},
+				Player:            p,
+				ClipboardListener: make(chan string, 1),
+			}
+
+			err := a.Play("https://clips.twitch.tv/foo", "foo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Play() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(p.played, tt.want) {
+				t.Errorf("Play() played = %v, want %v", p.played, tt.want)
+			}
+			if err == nil {
+				if got := <-a.ClipboardListener; got != tt.want[0] {
+					t.Errorf("Play() clipboard = %v, want %v", got, tt.want[0])
+				}
+			}
+		})
+	}
+}
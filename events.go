@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// onlineRetryDelay is the time to wait before polling when a stream is announced online
+	// but not yet listed by the Helix API
+	onlineRetryDelay = time.Second * 15
+
+	minFollowedBackoff = time.Second     // first delay before listing followed channels again
+	maxFollowedBackoff = time.Minute * 5 // maximum delay between two attempts
+)
+
+// ListenEvents receives EventSub events for every followed channel and sends updated active streams to out.
+// Followed channels are listed again with backoff until it succeeds, streams are polled meanwhile.
+func (a *Application) ListenEvents(ctx context.Context, out chan<- []*twitch.Stream) {
+	broadcasterIDs, ok := a.followedChannelIDs(ctx)
+	if !ok {
+		log.Debugln("received context cancel: ListenEvents")
+		return
+	}
+
+	events := make(chan *twitch.Event)
+	go func() {
+		defer close(events)
+		err := a.client().EventSub.Listen(ctx, broadcasterIDs, events)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Errorf("eventsub stopped, falling back to polling: %s", err)
+		}
+	}()
+
+	for event := range events {
+		a.HandleEvent(ctx, event, len(broadcasterIDs), out)
+	}
+
+	a.eventSubActive.Store(false)
+	log.Debugln("received context cancel: ListenEvents")
+}
+
+// followedChannelIDs lists the IDs of followed channels, retrying with backoff until ctx is done.
+// It returns false once ctx is done.
+func (a *Application) followedChannelIDs(ctx context.Context) ([]string, bool) {
+	backoff := minFollowedBackoff
+	for {
+		channels, err := a.client().Channels.GetFollowed(ctx)
+		if err == nil {
+			broadcasterIDs := make([]string, 0, len(channels))
+			for _, c := range channels {
+				broadcasterIDs = append(broadcasterIDs, c.BroadcasterID)
+			}
+
+			return broadcasterIDs, true
+		}
+
+		if ctx.Err() == nil {
+			log.Warningf("unable to list followed channels, polling until retried in %s: %s", backoff, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxFollowedBackoff {
+			backoff = maxFollowedBackoff
+		}
+	}
+}
+
+// HandleEvent updates active streams from given event.
+// watched is the number of channels EventSub should watch.
+func (a *Application) HandleEvent(ctx context.Context, event *twitch.Event, watched int, out chan<- []*twitch.Stream) {
+	log.WithFields(map[string]interface{}{
+		"type":  event.Type,
+		"login": event.BroadcasterUserLogin,
+	}).Debugln("received eventsub event")
+
+	switch event.Type {
+	case twitch.EventConnected:
+		// Polling only reconciles once every followed channel is watched
+		a.eventSubActive.Store(len(event.Subscribed) == watched)
+
+		// Events may have been missed while disconnected
+		a.RefreshNow()
+	case twitch.EventStreamOnline:
+		streams, err := a.client().Streams.GetStream(ctx, event.BroadcasterUserLogin)
+		if err != nil || len(streams) == 0 {
+			// Helix may not list it yet
+			log.Debugf("stream [%s] not listed yet, polling in %s", event.BroadcasterUserLogin, onlineRetryDelay)
+			time.AfterFunc(onlineRetryDelay, a.RefreshNow)
+			return
+		}
+
+		a.classifyStreams(ctx, streams[:1])
+		a.PublishActiveStreams(ctx, out, func(active []*twitch.Stream) []*twitch.Stream {
+			return append(withoutStream(active, event.BroadcasterUserID), streams[0])
+		})
+	case twitch.EventStreamOffline:
+		a.PublishActiveStreams(ctx, out, func(active []*twitch.Stream) []*twitch.Stream {
+			return withoutStream(active, event.BroadcasterUserID)
+		})
+	case twitch.EventChannelUpdate:
+		a.PublishActiveStreams(ctx, out, func(active []*twitch.Stream) []*twitch.Stream {
+			updated := make([]*twitch.Stream, len(active))
+			for i, s := range active {
+				updated[i] = s
+				if s.UserID != event.BroadcasterUserID {
+					continue
+				}
+
+				// Streams already sent must not change
+				stream := *s
+				stream.Title = event.Title
+				stream.GameID = event.CategoryID
+				stream.GameName = event.CategoryName
+				stream.ContentClassificationLabels = event.ContentClassificationLabels
+				if event.Language != "" {
+					stream.Language = event.Language
+				}
+				updated[i] = &stream
+			}
+
+			return updated
+		})
+	}
+}
+
+// withoutStream returns streams without the one of given user ID
+func withoutStream(streams []*twitch.Stream, userID string) []*twitch.Stream {
+	result := make([]*twitch.Stream, 0, len(streams))
+	for _, s := range streams {
+		if s.UserID != userID {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
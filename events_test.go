@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+// receiveStreams waits for the next active streams list and returns its titles by login
+func receiveStreams(t *testing.T, in <-chan []*twitch.Stream) map[string]string {
+	t.Helper()
+	select {
+	case streams := <-in:
+		got := make(map[string]string, len(streams))
+		for _, s := range streams {
+			got[s.UserLogin] = s.Title
+		}
+
+		return got
+	case <-time.After(time.Second * 5):
+		t.Fatalf("no active streams received")
+		return nil
+	}
+}
+
+func TestApplication_HandleEvent(t *testing.T) {
+	active := []*twitch.Stream{
+		{ID: "s2", UserID: "2", UserLogin: "bar", Title: "bar title"},
+		{ID: "s3", UserID: "3", UserLogin: "baz", Title: "baz title"},
+	}
+
+	tests := []struct {
+		name  string
+		event *twitch.Event
+		want  map[string]string // title by login
+	}{
+		{
+			name:  "Expected stream online",
+			event: &twitch.Event{Type: twitch.EventStreamOnline, BroadcasterUserID: "4", BroadcasterUserLogin: "qux"},
+			want:  map[string]string{"bar": "bar title", "baz": "baz title", "qux": "qux title"},
+		},
+		{
+			name:  "Expected stream offline",
+			event: &twitch.Event{Type: twitch.EventStreamOffline, BroadcasterUserID: "2", BroadcasterUserLogin: "bar"},
+			want:  map[string]string{"baz": "baz title"},
+		},
+		{
+			name:  "Expected channel update",
+			event: &twitch.Event{Type: twitch.EventChannelUpdate, BroadcasterUserID: "3", BroadcasterUserLogin: "baz", Title: "new title"},
+			want:  map[string]string{"bar": "bar title", "baz": "new title"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := twitchtest.NewServer()
+			defer srv.Close()
+
+			srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"})
+			srv.AddStream(&twitchtest.Stream{ID: "s4", UserID: "4", UserLogin: "qux", Title: "qux title"})
+
+			a := newTestApplication(t, srv)
+			a.activeStreams = active
+
+			out := make(chan []*twitch.Stream, 1)
+			a.HandleEvent(context.Background(), tt.event, 2, out)
+
+			if got := receiveStreams(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HandleEvent() got = %v, want %v", got, tt.want)
+			}
+
+			// streams already sent must not change
+			if active[1].Title != "baz title" {
+				t.Errorf("HandleEvent() changed a stream already sent")
+			}
+		})
+	}
+}
+
+func TestApplication_ListenEvents(t *testing.T) {
+	srv := twitchtest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser(&twitchtest.User{ID: "1", Login: "foo"}, &twitchtest.User{ID: "2", Login: "bar"})
+	srv.Follow("2")
+
+	// a failed listing of followed channels is retried
+	srv.FailTimes("/channels/followed", http.StatusBadRequest, "foo", 1)
+
+	a := newTestApplication(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(chan []*twitch.Stream, 1)
+	go a.ListenEvents(ctx, out)
+
+	// Wait for the subscriptions, then every followed channel is watched
+	deadline := time.Now().Add(time.Second * 5)
+	for len(srv.Subscriptions()) < 3 || !a.eventSubActive.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("ListenEvents() did not subscribe")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if got := a.refreshInterval(); got != reconcileInterval {
+		t.Errorf("refreshInterval() = %s, want %s", got, reconcileInterval)
+	}
+
+	srv.AddStream(&twitchtest.Stream{ID: "s2", UserID: "2", UserLogin: "bar", Title: "live"})
+	srv.SendEvent(twitch.EventStreamOnline, &twitchtest.Event{BroadcasterUserID: "2", BroadcasterUserLogin: "bar", Type: "live"})
+	if got := receiveStreams(t, out); !reflect.DeepEqual(got, map[string]string{"bar": "live"}) {
+		t.Errorf("ListenEvents() got = %v, want bar live", got)
+	}
+
+	srv.SendEvent(twitch.EventStreamOffline, &twitchtest.Event{BroadcasterUserID: "2", BroadcasterUserLogin: "bar"})
+	if got := receiveStreams(t, out); len(got) != 0 {
+		t.Errorf("ListenEvents() got = %v, want no stream", got)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	log "github.com/sirupsen/logrus"
+)
+
+// matchesFilter returns whether given stream matches any rule of f. Unclassified streams match any label rule.
+func matchesFilter(f config.StreamFilter, s *twitch.Stream) bool {
+	if f.Mature && s.IsMature {
+		return true
+	}
+
+	if len(f.Labels) > 0 && s.Unclassified {
+		return true
+	}
+
+	return containsFold(f.Languages, s.Language) ||
+		anyContainsFold(f.Tags, s.Tags) ||
+		anyContainsFold(f.Labels, s.ContentClassificationLabels)
+}
+
+// containsFold returns whether values contains v, case-insensitively
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyContainsFold returns whether values contains any of given ones, case-insensitively
+func anyContainsFold(values, others []string) bool {
+	for _, v := range others {
+		if containsFold(values, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// visibleStreams returns given streams without the hidden ones
+func (a *Application) visibleStreams(streams []*twitch.Stream) []*twitch.Stream {
+	visible := make([]*twitch.Stream, 0, len(streams))
+	for _, s := range streams {
+		if matchesFilter(a.config.Hide, s) {
+			log.Tracef("hiding stream [%s]", s.UserLogin)
+			continue
+		}
+
+		visible = append(visible, s)
+	}
+
+	return visible
+}
+
+// muted returns whether given stream is displayed without notification
+func (a *Application) muted(s *twitch.Stream) bool {
+	return matchesFilter(a.config.Mute, s)
+}
+
+// classifyStreams sets the content classification labels of given streams, read from their channel.
+// Nothing is requested when no filter uses labels. If the channels cannot be read, streams already active keep
+// their labels and the other ones are marked unclassified, not to show streams a filter would hide.
+func (a *Application) classifyStreams(ctx context.Context, streams []*twitch.Stream) {
+	if len(streams) == 0 || len(a.config.Hide.Labels)+len(a.config.Mute.Labels) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(streams))
+	for _, s := range streams {
+		ids = append(ids, s.UserID)
+	}
+
+	channels, err := a.client().Channels.Get(ctx, ids...)
+	if err != nil {
+		log.Errorf("unable to read content classification labels: %s", err)
+		previous := make(map[string]*twitch.Stream)
+		for _, s := range a.ActiveStreams() {
+			previous[s.UserID] = s
+		}
+
+		for _, s := range streams {
+			if p, ok := previous[s.UserID]; ok {
+				s.ContentClassificationLabels, s.Unclassified = p.ContentClassificationLabels, p.Unclassified
+				continue
+			}
+
+			s.ContentClassificationLabels, s.Unclassified = nil, true
+		}
+
+		return
+	}
+
+	labels := make(map[string][]string, len(channels))
+	for _, c := range channels {
+		labels[c.BroadcasterID] = c.ContentClassificationLabels
+	}
+
+	for _, s := range streams {
+		s.ContentClassificationLabels, s.Unclassified = labels[s.UserID], false
+	}
+}
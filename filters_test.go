@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_matchesFilter(t *testing.T) {
+	stream := &twitch.Stream{
+		Language:                    "de",
+		Tags:                        []string{"English", "Speedrun"},
+		ContentClassificationLabels: []string{"Gambling"},
+	}
+
+	tests := []struct {
+		name   string
+		filter config.StreamFilter
+		stream *twitch.Stream
+		want   bool
+	}{
+		{name: "Expected empty filter not matching", stream: stream},
+		{name: "Expected tag matched case-insensitively", filter: config.StreamFilter{Tags: []string{"speedrun"}}, stream: stream, want: true},
+		{name: "Expected label matched", filter: config.StreamFilter{Labels: []string{"MatureGame", "Gambling"}}, stream: stream, want: true},
+		{name: "Expected unclassified stream matched", filter: config.StreamFilter{Labels: []string{"MatureGame"}}, stream: &twitch.Stream{Unclassified: true}, want: true},
+		{name: "Expected unclassified stream ignored without label filter", filter: config.StreamFilter{Tags: []string{"French"}}, stream: &twitch.Stream{Unclassified: true}, want: false},
+		{name: "Expected label named unknown not matched by classified streams", filter: config.StreamFilter{Labels: []string{"unknown"}}, stream: &twitch.Stream{ContentClassificationLabels: []string{"Gambling"}}, want: false},
+		{name: "Expected language matched", filter: config.StreamFilter{Languages: []string{"DE"}}, stream: stream, want: true},
+		{name: "Expected other language not matching", filter: config.StreamFilter{Languages: []string{"fr"}}, stream: stream},
+		{name: "Expected mature stream matched", filter: config.StreamFilter{Mature: true}, stream: &twitch.Stream{IsMature: true}, want: true},
+		{name: "Expected other stream not matching", filter: config.StreamFilter{Mature: true}, stream: stream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesFilter(tt.filter, tt.stream); got != tt.want {
+				t.Errorf("matchesFilter() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplication_visibleStreams(t *testing.T) {
+	a := &Application{config: &config.Config{Hide: config.StreamFilter{Mature: true, Labels: []string{"SexualThemes"}}}}
+	streams := []*twitch.Stream{
+		{UserLogin: "foo"},
+		{UserLogin: "bar", IsMature: true},
+		{UserLogin: "baz", ContentClassificationLabels: []string{"SexualThemes"}},
+	}
+
+	got := make([]string, 0)
+	for _, s := range a.visibleStreams(streams) {
+		got = append(got, s.UserLogin)
+	}
+	if want := []string{"foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visibleStreams() got = %v, want %v", got, want)
+	}
+}
+
+func TestApplication_classifyStreams(t *testing.T) {
+	tests := []struct {
+		name             string
+		filter           config.StreamFilter
+		fail             bool                // channels cannot be read
+		active           []*twitch.Stream    // streams already active
+		want             map[string][]string // labels by login
+		wantUnclassified []string
+		requests         int
+	}{
+		{
+			name:     "Expected labels read from channels",
+			filter:   config.StreamFilter{Labels: []string{"Gambling"}},
+			want:     map[string][]string{"bar": {"Gambling"}, "baz": nil},
+			requests: 1,
+		},
+		{
+			name:   "Expected nothing requested without label filter",
+			filter: config.StreamFilter{Mature: true},
+			want:   map[string][]string{"bar": nil, "baz": nil},
+		},
+		{
+			name:             "Expected previous labels or unclassified when channels cannot be read",
+			filter:           config.StreamFilter{Labels: []string{"Gambling"}},
+			fail:             true,
+			active:           []*twitch.Stream{{UserID: "2", UserLogin: "bar", ContentClassificationLabels: []string{"Gambling"}}},
+			want:             map[string][]string{"bar": {"Gambling"}, "baz": nil},
+			wantUnclassified: []string{"baz"},
+			requests:         1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := twitchtest.NewServer()
+			defer srv.Close()
+			srv.AddUser(
+				&twitchtest.User{ID: "1", Login: "foo"},
+				&twitchtest.User{ID: "2", Login: "bar"},
+				&twitchtest.User{ID: "3", Login: "baz"},
+			)
+			srv.AddChannel(&twitchtest.Channel{BroadcasterID: "2", ContentClassificationLabels: []string{"Gambling"}})
+			if tt.fail {
+				srv.Fail("/channels", http.StatusBadRequest, "foo")
+			}
+
+			a := newTestApplication(t, srv)
+			a.config.Mute = tt.filter
+			a.activeStreams = tt.active
+			streams := []*twitch.Stream{{UserID: "2", UserLogin: "bar"}, {UserID: "3", UserLogin: "baz"}}
+			a.classifyStreams(context.Background(), streams)
+
+			got := make(map[string][]string, len(streams))
+			var unclassified []string
+			for _, s := range streams {
+				got[s.UserLogin] = s.ContentClassificationLabels
+				if s.Unclassified {
+					unclassified = append(unclassified, s.UserLogin)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("classifyStreams() labels = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(unclassified, tt.wantUnclassified) {
+				t.Errorf("classifyStreams() unclassified = %v, want %v", unclassified, tt.wantUnclassified)
+			}
+			if got := srv.Requests("/channels"); got != tt.requests {
+				t.Errorf("classifyStreams() requests = %v, want %v", got, tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+// Package chat reads Twitch chat over IRC on WebSocket
+// https://dev.twitch.tv/docs/irc/
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	DefaultURL = "wss://irc-ws.chat.twitch.tv:443"
+
+	loginTimeout = time.Second * 10 // Twitch answers the login right away
+	readTimeout  = time.Minute * 6  // Twitch sends a PING about every 5 minutes
+	minBackoff   = time.Second
+	maxBackoff   = time.Minute * 2
+	joinBatch    = 20               // channels joined by each JOIN command
+	joinInterval = time.Second * 10 // at most joinBatch channels are joined during this delay, as allowed by Twitch
+
+	capabilities = "twitch.tv/tags twitch.tv/commands"
+)
+
+var (
+	// ErrAuthentication is returned when Twitch refuses the login or token, retrying will not help
+	ErrAuthentication = errors.New("twitch chat authentication failed")
+
+	// errReconnect is returned by a session when Twitch asks to reconnect
+	errReconnect = errors.New("twitch chat asked to reconnect")
+)
+
+// Config of a chat Client
+type Config struct {
+	// URL of the IRC WebSocket server. Defaults to wss://irc-ws.chat.twitch.tv:443
+	URL string
+
+	// Login of the user to read the chat as. The chat is read anonymously when empty.
+	Login string
+
+	// Token returns a user access token with the chat:read scope. Required with Login.
+	Token func() (string, error)
+
+	// FallbackAnonymous reads the chat anonymously when Twitch refuses the login or token, instead of failing
+	FallbackAnonymous bool
+}
+
+// Client reads the chat of Twitch channels
+type Client struct {
+	url          string
+	login        string
+	token        func() (string, error)
+	minBackoff   time.Duration // first reconnection delay, doubled on each failure
+	joinInterval time.Duration // at most joinBatch channels are joined during this delay
+	fallback     bool          // read anonymously when the login is refused
+
+	mutex    sync.Mutex
+	channels map[string]bool // channels to join, without #
+	changed  chan struct{}   // wakes the connection up when channels change
+}
+
+// New returns a chat Client
+func New(config *Config) (*Client, error) {
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.Login != "" && config.Token == nil {
+		return nil, fmt.Errorf("missing token of chat user %s", config.Login)
+	}
+
+	c := &Client{
+		url:          config.URL,
+		login:        strings.ToLower(config.Login),
+		token:        config.Token,
+		minBackoff:   minBackoff,
+		joinInterval: joinInterval,
+		fallback:     config.FallbackAnonymous,
+		channels:     make(map[string]bool),
+		changed:      make(chan struct{}, 1),
+	}
+
+	if c.url == "" {
+		c.url = DefaultURL
+	}
+
+	return c, nil
+}
+
+// Join reads the chat of given channels, on the current connection if any, or once connected.
+// Channels already joined are ignored.
+func (c *Client) Join(channels ...string) {
+	c.update(channels, true)
+}
+
+// Part stops reading the chat of given channels
+func (c *Client) Part(channels ...string) {
+	c.update(channels, false)
+}
+
+// Channels returns the channels to read, sorted
+func (c *Client) Channels() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	channels := make([]string, 0, len(c.channels))
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+
+	sort.Strings(channels)
+	return channels
+}
+
+// update adds or removes given channels and wakes the connection up
+func (c *Client) update(channels []string, join bool) {
+	c.mutex.Lock()
+	for _, channel := range channels {
+		channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+		if join {
+			c.channels[channel] = true
+		} else {
+			delete(c.channels, channel)
+		}
+	}
+	c.mutex.Unlock()
+
+	select {
+	case c.changed <- struct{}{}:
+	default: // already woken up
+	}
+}
+
+// pending returns the channels to join and the ones to part, sorted, given the joined ones
+func (c *Client) pending(joined map[string]bool) (join, part []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for channel := range c.channels {
+		if !joined[channel] {
+			join = append(join, channel)
+		}
+	}
+
+	for channel := range joined {
+		if !c.channels[channel] {
+			part = append(part, channel)
+		}
+	}
+
+	sort.Strings(join)
+	sort.Strings(part)
+	return join, part
+}
+
+// Listen sends the messages of the joined channels to out until ctx is done, on a single connection.
+// Channels can be joined and parted meanwhile, they are joined again on reconnection.
+// PING messages, reconnect requests and connection failures are handled, it only returns on fatal errors
+// such as ErrAuthentication. A Client must not listen twice at the same time.
+func (c *Client) Listen(ctx context.Context, out chan<- *Message) error {
+	backoff := c.minBackoff
+	for {
+		joined, err := c.session(ctx, out)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrAuthentication) && c.fallback && c.login != "":
+			log.Warningf("%s, reading chat anonymously", err)
+			c.login = ""
+			continue
+		case errors.Is(err, ErrAuthentication):
+			return err
+		case errors.Is(err, errReconnect):
+			log.Debugln("chat: reconnect requested")
+			backoff = c.minBackoff
+			continue
+		case joined:
+			backoff = c.minBackoff
+		}
+
+		log.Warningf("chat connection lost, reconnecting in %s: %s", backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// session runs a single connection, from login to connection loss. It returns whether it logged in.
+func (c *Client) session(ctx context.Context, out chan<- *Message) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	config, err := websocket.NewConfig(c.url, "http://localhost/")
+	if err != nil {
+		return false, fmt.Errorf("invalid chat URL: %w", err)
+	}
+	config.Dialer = &net.Dialer{Timeout: loginTimeout}
+
+	log.Debugf("chat: connecting to %s", c.url)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return false, fmt.Errorf("unable to connect to chat: %w", err)
+	}
+
+	// Unblock reads when ctx is done
+	var once sync.Once
+	closeConn := func() { once.Do(func() { _ = conn.Close() }) }
+	defer closeConn()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			closeConn()
+		}
+	}()
+
+	r := &reader{conn: conn}
+	if err := c.authenticate(r); err != nil {
+		return false, err
+	}
+
+	// Channels are joined in the background, answering PINGs meanwhile
+	go func() {
+		if err := c.sync(ctx, r); err != nil && ctx.Err() == nil {
+			log.Warningf("chat: %s", err)
+			closeConn()
+		}
+	}()
+
+	if !send(ctx, out, &Message{Type: MessageConnected}) {
+		return true, ctx.Err()
+	}
+
+	for {
+		line, err := r.read(readTimeout)
+		if err != nil {
+			return true, err
+		}
+
+		switch line.Command {
+		case "PING":
+			if err := r.write("PONG :" + line.Param(0)); err != nil {
+				return true, err
+			}
+		case "RECONNECT":
+			return true, errReconnect
+		default:
+			msg, ok := newMessage(line)
+			if !ok {
+				log.Tracef("chat: ignoring %s", line.Command)
+				continue
+			}
+
+			if !send(ctx, out, msg) {
+				return true, ctx.Err()
+			}
+		}
+	}
+}
+
+// sync sends JOIN and PART commands until ctx is done, following the channels to read.
+// At most joinBatch channels are joined every joinInterval: Twitch drops the connection of users joining faster.
+func (c *Client) sync(ctx context.Context, r *reader) error {
+	joined := make(map[string]bool)
+	var recent []time.Time // when each channel of the last joinInterval was joined
+	for {
+		join, part := c.pending(joined)
+		if len(part) > 0 {
+			if err := r.write("PART " + channelList(part)); err != nil {
+				return err
+			}
+
+			for _, channel := range part {
+				delete(joined, channel)
+			}
+		}
+
+		now := time.Now()
+		for len(recent) > 0 && now.Sub(recent[0]) >= c.joinInterval {
+			recent = recent[1:]
+		}
+
+		if n := joinBatch - len(recent); len(join) > 0 && n > 0 {
+			if len(join) > n {
+				join = join[:n]
+			}
+
+			if err := r.write("JOIN " + channelList(join)); err != nil {
+				return err
+			}
+
+			for _, channel := range join {
+				joined[channel] = true
+				recent = append(recent, now)
+			}
+
+			continue
+		}
+
+		var later <-chan time.Time // channels left to join once the oldest join expires
+		if len(join) > 0 {
+			later = time.After(c.joinInterval - now.Sub(recent[0]))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.changed:
+		case <-later:
+		}
+	}
+}
+
+// channelList returns the parameter of a JOIN or PART command
+func channelList(channels []string) string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, "#"+channel)
+	}
+
+	return strings.Join(names, ",")
+}
+
+// authenticate requests the capabilities and logs in, waiting for the welcome message
+func (c *Client) authenticate(r *reader) error {
+	nick, pass := c.login, ""
+	if nick == "" {
+		nick = "justinfan" + strconv.Itoa(10000+rand.Intn(90000)) // #nosec G404 -- anonymous nickname
+	} else {
+		token, err := c.token()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrAuthentication, err)
+		}
+
+		pass = "oauth:" + strings.TrimPrefix(token, "oauth:")
+	}
+
+	commands := []string{"CAP REQ :" + capabilities}
+	if pass != "" {
+		commands = append(commands, "PASS "+pass)
+	}
+
+	commands = append(commands, "NICK "+nick)
+	for _, command := range commands {
+		if err := r.write(command); err != nil {
+			return err
+		}
+	}
+
+	for {
+		line, err := r.read(loginTimeout)
+		if err != nil {
+			return err
+		}
+
+		switch line.Command {
+		case "001":
+			log.Debugf("chat: logged in as %s", nick)
+			return nil
+		case "NOTICE":
+			// Login authentication failed, Improperly formatted auth…
+			return fmt.Errorf("%w: %s", ErrAuthentication, line.Param(1))
+		case "PING":
+			if err := r.write("PONG :" + line.Param(0)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reader reads the lines of a connection, a WebSocket message may carry several lines
+type reader struct {
+	conn    *websocket.Conn
+	pending []string
+}
+
+// read returns the next line, failing if nothing is received within timeout. Invalid lines are skipped.
+func (r *reader) read(timeout time.Duration) (*Line, error) {
+	for {
+		for len(r.pending) == 0 {
+			if err := r.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				return nil, err
+			}
+
+			var data string
+			if err := websocket.Message.Receive(r.conn, &data); err != nil {
+				return nil, fmt.Errorf("unable to read chat message: %w", err)
+			}
+
+			for _, s := range strings.Split(data, "\r\n") {
+				if s != "" {
+					r.pending = append(r.pending, s)
+				}
+			}
+		}
+
+		s := r.pending[0]
+		r.pending = r.pending[1:]
+
+		line, err := ParseLine(s)
+		if err != nil {
+			log.Debugf("chat: skipping %q: %s", s, err)
+			continue
+		}
+
+		return line, nil
+	}
+}
+
+// write sends a single line, it is safe for concurrent use
+func (r *reader) write(s string) error {
+	if err := websocket.Message.Send(r.conn, s+"\r\n"); err != nil {
+		return fmt.Errorf("unable to send chat message: %w", err)
+	}
+
+	return nil
+}
+
+// send sends msg to out unless ctx is done first
+func send(ctx context.Context, out chan<- *Message, msg *Message) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- msg:
+		return true
+	}
+}
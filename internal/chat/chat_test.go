@@ -0,0 +1,267 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/chat/chattest"
+)
+
+// listen joins channels, starts c.Listen and returns its messages and error
+func listen(t *testing.T, c *Client, channels ...string) (<-chan *Message, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	out := make(chan *Message, 10)
+	errs := make(chan error, 1)
+	c.Join(channels...)
+	go func() { errs <- c.Listen(ctx, out) }()
+	return out, errs
+}
+
+// receive returns the next message of given type, failing after a second
+func receive(t *testing.T, out <-chan *Message, messageType MessageType) *Message {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-out:
+			if msg.Type == messageType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("Listen() no %s message received", messageType)
+		}
+	}
+}
+
+func TestClient_Listen(t *testing.T) {
+	srv := chattest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  *Message
+	}{
+		{
+			name:  "Expected chat message",
+			lines: []string{`@badges=moderator/1;color=#1E90FF;display-name=Foo;id=abc;tmi-sent-ts=1684267200000 :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hello world`},
+			want: &Message{
+				Type: MessageChat, ID: "abc", Channel: "bar", User: "foo", DisplayName: "Foo", Color: "#1E90FF",
+				Badges: []string{"moderator/1"}, Text: "hello world", SentAt: time.UnixMilli(1684267200000),
+				Tags: map[string]string{"badges": "moderator/1", "color": "#1E90FF", "display-name": "Foo", "id": "abc", "tmi-sent-ts": "1684267200000"},
+			},
+		},
+		{
+			name:  "Expected action",
+			lines: []string{":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :\x01ACTION waves\x01"},
+			want:  &Message{Type: MessageChat, Channel: "bar", User: "foo", DisplayName: "foo", Text: "waves", Action: true, Tags: map[string]string{}},
+		},
+		{
+			name:  "Expected system message of user notice",
+			lines: []string{`@login=foo;msg-id=raid;system-msg=5\sraiders\sfrom\sFoo :tmi.twitch.tv USERNOTICE #bar`},
+			want: &Message{
+				Type: MessageUserNotice, Channel: "bar", User: "foo", DisplayName: "foo", Text: "5 raiders from Foo",
+				Tags: map[string]string{"login": "foo", "msg-id": "raid", "system-msg": "5 raiders from Foo"},
+			},
+		},
+		{
+			name:  "Expected ignored lines skipped",
+			lines: []string{":tmi.twitch.tv 353 justinfan1 = #bar :justinfan1", "@", ":tmi.twitch.tv CLEARCHAT #bar :foo"},
+			want:  &Message{Type: MessageClearChat, Channel: "bar", User: "foo", DisplayName: "foo", Tags: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(&Config{URL: srv.WebSocketURL()})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			out, _ := listen(t, c, "Bar")
+			receive(t, out, MessageConnected)
+			if !srv.WaitJoined(time.Second, "bar") {
+				t.Fatalf("Listen() channel not joined")
+			}
+
+			srv.Send("bar", tt.lines...)
+			if got := receive(t, out, tt.want.Type); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Listen() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Listen_ping(t *testing.T) {
+	srv := chattest.NewServer()
+	defer srv.Close()
+
+	c, _ := New(&Config{URL: srv.WebSocketURL()})
+	out, _ := listen(t, c, "bar")
+	receive(t, out, MessageConnected)
+
+	srv.Ping()
+	deadline := time.Now().Add(time.Second)
+	for len(srv.Pongs()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if got, want := srv.Pongs(), []string{"tmi.twitch.tv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Listen() pongs = %v, want %v", got, want)
+	}
+}
+
+func TestClient_Listen_reconnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		disconnect func(*chattest.Server)
+	}{
+		{name: "Expected reconnected on request", disconnect: (*chattest.Server).Reconnect},
+		{name: "Expected reconnected after connection loss", disconnect: (*chattest.Server).CloseConnections},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := chattest.NewServer()
+			defer srv.Close()
+
+			c, _ := New(&Config{URL: srv.WebSocketURL()})
+			c.minBackoff = time.Millisecond
+			out, _ := listen(t, c, "bar")
+			receive(t, out, MessageConnected)
+
+			tt.disconnect(srv)
+			receive(t, out, MessageConnected)
+			if !srv.WaitJoined(time.Second, "bar") {
+				t.Fatalf("Listen() channel not joined again")
+			}
+
+			if got := srv.Connections(); got != 2 {
+				t.Errorf("Listen() connections = %v, want 2", got)
+			}
+		})
+	}
+}
+
+func TestClient_Listen_join(t *testing.T) {
+	srv := chattest.NewServer()
+	defer srv.Close()
+
+	channels := make([]string, 45)
+	for i := range channels {
+		channels[i] = fmt.Sprintf("channel%d", i)
+	}
+
+	c, _ := New(&Config{URL: srv.WebSocketURL()})
+	c.joinInterval = time.Millisecond * 200
+	out, _ := listen(t, c, channels...)
+	receive(t, out, MessageConnected)
+	if !srv.WaitJoined(time.Second*2, channels...) {
+		t.Fatalf("Listen() channels not joined")
+	}
+
+	joins := srv.Joins()
+	var sizes []int
+	for _, join := range joins {
+		sizes = append(sizes, len(join.Channels))
+	}
+	if want := []int{20, 20, 5}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("Listen() JOIN batches = %v, want %v", sizes, want)
+	}
+
+	// a margin is left for the delivery of each command
+	for i := 1; i < len(joins); i++ {
+		if got := joins[i].At.Sub(joins[i-1].At); got < c.joinInterval*9/10 {
+			t.Errorf("Listen() JOIN %d sent %s after the previous one, want %s", i, got, c.joinInterval)
+		}
+	}
+}
+
+func TestClient_Join(t *testing.T) {
+	srv := chattest.NewServer()
+	defer srv.Close()
+
+	c, _ := New(&Config{URL: srv.WebSocketURL()})
+	out, _ := listen(t, c, "bar")
+	receive(t, out, MessageConnected)
+	if !srv.WaitJoined(time.Second, "bar") {
+		t.Fatalf("Listen() channel not joined")
+	}
+
+	// channels change on the same connection
+	c.Join("#Baz")
+	c.Part("bar")
+	if !srv.WaitJoined(time.Second, "baz") {
+		t.Fatalf("Join() channel not joined")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !reflect.DeepEqual(srv.Joined(), []string{"baz"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Part() joined = %v, want [baz]", srv.Joined())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if got := srv.Connections(); got != 1 {
+		t.Errorf("Join() connections = %v, want 1", got)
+	}
+	if got, want := c.Channels(), []string{"baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Channels() got = %v, want %v", got, want)
+	}
+}
+
+func TestClient_Listen_authentication(t *testing.T) {
+	srv := chattest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		fallback bool
+		wantErr  error
+	}{
+		{name: "Expected logged in with token", token: chattest.Token},
+		{name: "Expected error with invalid token", token: "invalid", wantErr: ErrAuthentication},
+		{name: "Expected anonymous with invalid token", token: "invalid", fallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(&Config{
+				URL:               srv.WebSocketURL(),
+				Login:             "Foo",
+				Token:             func() (string, error) { return tt.token, nil },
+				FallbackAnonymous: tt.fallback,
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			out, errs := listen(t, c, "bar")
+			if tt.wantErr == nil {
+				receive(t, out, MessageConnected)
+				return
+			}
+
+			select {
+			case err := <-errs:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Listen() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("Listen() did not return")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&Config{Login: "foo"}); err == nil {
+		t.Errorf("New() without token error = %v, want an error", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package chattest provides a local stand-in of the Twitch IRC WebSocket server
+package chattest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Token is the only access token accepted by the fake server
+const Token = "chat-access-token"
+
+// Server is a fake Twitch IRC WebSocket server. Use its WebSocketURL as chat.Config URL.
+// Each method is safe to call while the server is running.
+type Server struct {
+	*httptest.Server
+
+	mutex       sync.Mutex
+	conns       map[*conn]struct{} // logged in connections
+	connections int                // number of connections ever made
+	pongs       []string           // PONG parameters received
+	joins       []Join             // JOIN commands received
+}
+
+// Join is a JOIN command received by the server
+type Join struct {
+	Channels []string // without #
+	At       time.Time
+}
+
+// conn is a client connection with the channels it joined
+type conn struct {
+	ws       *websocket.Conn
+	nick     string
+	channels map[string]bool // without #
+	mutex    sync.Mutex      // guards writes
+}
+
+// NewServer starts a fake IRC server, it must be closed when done
+func NewServer() *Server {
+	s := &Server{conns: make(map[*conn]struct{})}
+	s.Server = httptest.NewServer(websocket.Handler(s.handleConn))
+	return s
+}
+
+// WebSocketURL returns the URL to use as chat.Config URL
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// Connections returns the number of connections made to the server
+func (s *Server) Connections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.connections
+}
+
+// Joined returns the channels joined by every logged in connection, without #
+func (s *Server) Joined() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var channels []string
+	for c := range s.conns {
+		c.mutex.Lock()
+		for channel := range c.channels {
+			channels = append(channels, channel)
+		}
+		c.mutex.Unlock()
+	}
+
+	return channels
+}
+
+// WaitJoined waits until a connection joined every given channel, false after timeout
+func (s *Server) WaitJoined(timeout time.Duration, channels ...string) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		joined := make(map[string]bool)
+		for _, channel := range s.Joined() {
+			joined[channel] = true
+		}
+
+		all := true
+		for _, channel := range channels {
+			all = all && joined[channel]
+		}
+
+		if all {
+			return true
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	return false
+}
+
+// Pongs returns the parameters of the PONG messages received
+func (s *Server) Pongs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.pongs...)
+}
+
+// Joins returns the JOIN commands received, in order
+func (s *Server) Joins() []Join {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Join(nil), s.joins...)
+}
+
+// Send sends raw lines to every connection which joined channel, in a single WebSocket message.
+// It returns the number of connections the lines were sent to.
+func (s *Server) Send(channel string, lines ...string) int {
+	var sent int
+	for _, c := range s.connsOf(channel) {
+		if c.write(lines...) == nil {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+// Ping sends a PING to every logged in connection
+func (s *Server) Ping() {
+	for _, c := range s.connsOf("") {
+		_ = c.write("PING :tmi.twitch.tv")
+	}
+}
+
+// Reconnect asks every logged in connection to reconnect
+func (s *Server) Reconnect() {
+	for _, c := range s.connsOf("") {
+		_ = c.write(":tmi.twitch.tv RECONNECT")
+	}
+}
+
+// CloseConnections drops every connection, as a network failure would
+func (s *Server) CloseConnections() {
+	for _, c := range s.connsOf("") {
+		_ = c.ws.Close()
+	}
+}
+
+// connsOf returns the logged in connections which joined channel, all of them if channel is empty
+func (s *Server) connsOf(channel string) []*conn {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		c.mutex.Lock()
+		joined := channel == "" || c.channels[strings.TrimPrefix(channel, "#")]
+		c.mutex.Unlock()
+
+		if joined {
+			conns = append(conns, c)
+		}
+	}
+
+	return conns
+}
+
+// handleConn logs a client in and keeps its connection open until it leaves
+func (s *Server) handleConn(ws *websocket.Conn) {
+	s.mutex.Lock()
+	s.connections++
+	s.mutex.Unlock()
+
+	c := &conn{ws: ws, channels: make(map[string]bool)}
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, c)
+		s.mutex.Unlock()
+	}()
+
+	var pass string
+	var data string
+	for websocket.Message.Receive(ws, &data) == nil {
+		for _, line := range strings.Split(data, "\r\n") {
+			command, param, _ := strings.Cut(line, " ")
+			switch command {
+			case "CAP":
+				_ = c.write(":tmi.twitch.tv CAP * ACK :twitch.tv/tags twitch.tv/commands")
+			case "PASS":
+				pass = param
+			case "NICK":
+				anonymous := strings.HasPrefix(param, "justinfan") && pass == ""
+				if !anonymous && pass != "oauth:"+Token {
+					_ = c.write(":tmi.twitch.tv NOTICE * :Login authentication failed")
+					return
+				}
+
+				c.nick = param
+				_ = c.write(":tmi.twitch.tv 001 " + param + " :Welcome, GLHF!")
+				s.mutex.Lock()
+				s.conns[c] = struct{}{}
+				s.mutex.Unlock()
+			case "JOIN":
+				join := Join{At: time.Now()}
+				c.mutex.Lock()
+				for _, channel := range strings.Split(param, ",") {
+					join.Channels = append(join.Channels, strings.TrimPrefix(channel, "#"))
+					c.channels[strings.TrimPrefix(channel, "#")] = true
+				}
+				c.mutex.Unlock()
+
+				s.mutex.Lock()
+				s.joins = append(s.joins, join)
+				s.mutex.Unlock()
+			case "PART":
+				c.mutex.Lock()
+				for _, channel := range strings.Split(param, ",") {
+					delete(c.channels, strings.TrimPrefix(channel, "#"))
+				}
+				c.mutex.Unlock()
+			case "PONG":
+				s.mutex.Lock()
+				s.pongs = append(s.pongs, strings.TrimPrefix(param, ":"))
+				s.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// write sends lines in a single WebSocket message
+func (c *conn) write(lines ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return websocket.Message.Send(c.ws, strings.Join(lines, "\r\n")+"\r\n")
+}
@@ -0,0 +1,141 @@
+package chat
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidLine is returned when parsing a line which is not an IRC message
+var ErrInvalidLine = errors.New("invalid irc line")
+
+// Line is a raw IRC message with its IRCv3 tags
+// https://ircv3.net/specs/extensions/message-tags
+type Line struct {
+	Tags    map[string]string
+	Prefix  string // e.g. foo!foo@foo.tmi.twitch.tv, empty if not set
+	Command string // e.g. PRIVMSG or 001
+	Params  []string
+}
+
+// ParseLine parses a single IRC line, without its trailing CRLF
+func ParseLine(s string) (*Line, error) {
+	line := &Line{Tags: make(map[string]string)}
+	s = strings.TrimRight(s, "\r\n")
+
+	if strings.HasPrefix(s, "@") {
+		tags, rest, ok := strings.Cut(s[1:], " ")
+		if !ok {
+			return nil, ErrInvalidLine
+		}
+
+		for _, tag := range strings.Split(tags, ";") {
+			key, value, _ := strings.Cut(tag, "=")
+			line.Tags[key] = unescapeTag(value)
+		}
+
+		s = strings.TrimLeft(rest, " ")
+	}
+
+	if strings.HasPrefix(s, ":") {
+		prefix, rest, ok := strings.Cut(s[1:], " ")
+		if !ok {
+			return nil, ErrInvalidLine
+		}
+
+		line.Prefix = prefix
+		s = strings.TrimLeft(rest, " ")
+	}
+
+	for s != "" {
+		if strings.HasPrefix(s, ":") {
+			line.Params = append(line.Params, s[1:])
+			break
+		}
+
+		param, rest, _ := strings.Cut(s, " ")
+		if line.Command == "" {
+			line.Command = param
+		} else {
+			line.Params = append(line.Params, param)
+		}
+
+		s = strings.TrimLeft(rest, " ")
+	}
+
+	if line.Command == "" {
+		return nil, ErrInvalidLine
+	}
+
+	return line, nil
+}
+
+// Nick returns the nickname of the prefix, e.g. foo for foo!foo@foo.tmi.twitch.tv
+func (l *Line) Nick() string {
+	nick, _, _ := strings.Cut(l.Prefix, "!")
+	return nick
+}
+
+// Param returns the i-th parameter, empty if there is none
+func (l *Line) Param(i int) string {
+	if i < len(l.Params) {
+		return l.Params[i]
+	}
+
+	return ""
+}
+
+// String formats the line as sent on the wire, without tags and CRLF
+func (l *Line) String() string {
+	var b strings.Builder
+	if l.Prefix != "" {
+		b.WriteString(":" + l.Prefix + " ")
+	}
+
+	b.WriteString(l.Command)
+	for i, param := range l.Params {
+		b.WriteString(" ")
+		if i == len(l.Params)-1 && (param == "" || strings.ContainsAny(param, " :")) {
+			b.WriteString(":")
+		}
+
+		b.WriteString(param)
+	}
+
+	return b.String()
+}
+
+// unescapeTag unescapes a tag value
+// https://ircv3.net/specs/extensions/message-tags#escaping-values
+func unescapeTag(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			break // a trailing backslash is dropped
+		}
+
+		switch s[i] {
+		case ':':
+			b.WriteByte(';')
+		case 's':
+			b.WriteByte(' ')
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i]) // includes \\
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    *Line
+		wantErr error
+	}{
+		{
+			name: "Expected PING",
+			s:    "PING :tmi.twitch.tv\r\n",
+			want: &Line{Tags: map[string]string{}, Command: "PING", Params: []string{"tmi.twitch.tv"}},
+		},
+		{
+			name: "Expected PRIVMSG with tags",
+			s:    `@badges=moderator/1;color=#1E90FF;display-name=Foo;emotes=;id=abc;tmi-sent-ts=1684267200000 :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hello world`,
+			want: &Line{
+				Tags:    map[string]string{"badges": "moderator/1", "color": "#1E90FF", "display-name": "Foo", "emotes": "", "id": "abc", "tmi-sent-ts": "1684267200000"},
+				Prefix:  "foo!foo@foo.tmi.twitch.tv",
+				Command: "PRIVMSG",
+				Params:  []string{"#bar", "hello world"},
+			},
+		},
+		{
+			name: "Expected escaped tag values",
+			s:    `@system-msg=foo\ssubscribed\:\sthanks\\\n;flag :tmi.twitch.tv USERNOTICE #bar`,
+			want: &Line{
+				Tags:    map[string]string{"system-msg": "foo subscribed; thanks\\\n", "flag": ""},
+				Prefix:  "tmi.twitch.tv",
+				Command: "USERNOTICE",
+				Params:  []string{"#bar"},
+			},
+		},
+		{
+			name: "Expected params without trailing",
+			s:    ":tmi.twitch.tv 001 justinfan123 :Welcome, GLHF!",
+			want: &Line{Tags: map[string]string{}, Prefix: "tmi.twitch.tv", Command: "001", Params: []string{"justinfan123", "Welcome, GLHF!"}},
+		},
+		{
+			name:    "Expected error without command",
+			s:       ":tmi.twitch.tv",
+			wantErr: ErrInvalidLine,
+		},
+		{
+			name:    "Expected error with tags only",
+			s:       "@id=abc",
+			wantErr: ErrInvalidLine,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLine(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLine() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLine_String(t *testing.T) {
+	tests := []struct {
+		name string
+		line *Line
+		want string
+	}{
+		{name: "Expected trailing", line: &Line{Command: "PRIVMSG", Params: []string{"#bar", "hello world"}}, want: "PRIVMSG #bar :hello world"},
+		{name: "Expected single word", line: &Line{Command: "JOIN", Params: []string{"#bar"}}, want: "JOIN #bar"},
+		{name: "Expected prefix", line: &Line{Prefix: "tmi.twitch.tv", Command: "PING", Params: []string{""}}, want: ":tmi.twitch.tv PING :"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.line.String(); got != tt.want {
+				t.Errorf("String() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
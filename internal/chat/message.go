@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MessageType is the kind of a chat message
+type MessageType string
+
+// Message types sent by Client.Listen
+const (
+	// MessageConnected is sent each time the channels are joined. Messages may have been missed before it.
+	MessageConnected  MessageType = "connected"
+	MessageChat       MessageType = "PRIVMSG"
+	MessageUserNotice MessageType = "USERNOTICE" // subscriptions, raids, announcements…
+	MessageClearChat  MessageType = "CLEARCHAT"  // user timed out or banned, chat cleared if User is empty
+	MessageClearMsg   MessageType = "CLEARMSG"   // message ID deleted
+	MessageNotice     MessageType = "NOTICE"
+	MessageRoomState  MessageType = "ROOMSTATE"
+)
+
+// actionPrefix and actionSuffix wrap /me messages
+const (
+	actionPrefix = "\x01ACTION "
+	actionSuffix = "\x01"
+)
+
+// Message describes a chat event
+type Message struct {
+	Type        MessageType
+	ID          string // message ID, the deleted one for MessageClearMsg
+	Channel     string // channel login, without #
+	User        string // author login, target of MessageClearChat
+	DisplayName string
+	Color       string   // e.g. #1E90FF, empty if the user never set one
+	Badges      []string // e.g. moderator/1
+	Text        string   // system message for MessageUserNotice without text
+	Action      bool     // sent with /me
+	SentAt      time.Time
+	Tags        map[string]string // every IRCv3 tag
+}
+
+// newMessage returns the chat message described by line, false if it is not one
+func newMessage(line *Line) (*Message, bool) {
+	msg := &Message{
+		Type:        MessageType(line.Command),
+		Channel:     strings.TrimPrefix(line.Param(0), "#"),
+		DisplayName: line.Tags["display-name"],
+		Color:       line.Tags["color"],
+		Tags:        line.Tags,
+	}
+
+	if ts, err := strconv.ParseInt(line.Tags["tmi-sent-ts"], 10, 64); err == nil {
+		msg.SentAt = time.UnixMilli(ts)
+	}
+
+	if badges := line.Tags["badges"]; badges != "" {
+		msg.Badges = strings.Split(badges, ",")
+	}
+
+	switch msg.Type {
+	case MessageChat:
+		msg.ID = line.Tags["id"]
+		msg.User = line.Nick()
+		msg.Text = line.Param(1)
+		if strings.HasPrefix(msg.Text, actionPrefix) && strings.HasSuffix(msg.Text, actionSuffix) {
+			msg.Text = strings.TrimSuffix(strings.TrimPrefix(msg.Text, actionPrefix), actionSuffix)
+			msg.Action = true
+		}
+	case MessageUserNotice:
+		msg.ID = line.Tags["id"]
+		msg.User = line.Tags["login"]
+		msg.Text = line.Param(1)
+		if msg.Text == "" {
+			msg.Text = line.Tags["system-msg"]
+		}
+	case MessageClearChat:
+		msg.User = line.Param(1)
+	case MessageClearMsg:
+		msg.ID = line.Tags["target-msg-id"]
+		msg.User = line.Tags["login"]
+		msg.Text = line.Param(1)
+	case MessageNotice:
+		msg.Text = line.Param(1)
+	case MessageRoomState:
+	default:
+		return nil, false
+	}
+
+	if msg.DisplayName == "" {
+		msg.DisplayName = msg.User
+	}
+
+	return msg, true
+}
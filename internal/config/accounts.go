@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const accountsFileName = "accounts.yaml"
+
+// Accounts lists the Twitch accounts used on this machine. It is saved by the app, not edited by the user.
+type Accounts struct {
+	// Current is the namespace of the account displayed in the tray, empty for the default account
+	Current string `yaml:"current"`
+
+	// Merge displays the followed live streams of every account instead of the current one only
+	Merge bool `yaml:"merge"`
+
+	Accounts []*Account `yaml:"accounts"`
+
+	mutex sync.Mutex
+	path  string // file the accounts are saved to, empty when the config directory is unknown
+}
+
+// Account is a Twitch account with its own session and cache
+type Account struct {
+	Namespace   string `yaml:"namespace"` // twitch.Config Account
+	Login       string `yaml:"login"`
+	DisplayName string `yaml:"display_name"`
+}
+
+// LoadAccounts loads the accounts stored on disk
+func LoadAccounts() *Accounts {
+	accounts := new(Accounts)
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Errorf("cannot load accounts: %v", err)
+		return accounts
+	}
+
+	accounts.path = filepath.Join(dir, configDirectoryName, accountsFileName)
+	data, err := os.ReadFile(accounts.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return accounts
+	}
+
+	if err != nil {
+		log.Errorf("cannot load accounts: %v", err)
+		return accounts
+	}
+
+	if err := yaml.Unmarshal(data, accounts); err != nil {
+		log.Errorf("cannot load accounts: %v", err)
+	}
+
+	return accounts
+}
+
+// Save writes the accounts on disk
+func (a *Accounts) Save() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.path == "" {
+		return errors.New("cannot save accounts: unknown config directory")
+	}
+
+	data, err := yaml.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("cannot save accounts: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("cannot save accounts: %w", err)
+	}
+
+	if err := os.WriteFile(a.path, data, 0644); err != nil {
+		return fmt.Errorf("cannot save accounts: %w", err)
+	}
+
+	return nil
+}
+
+// List returns a copy of the accounts
+func (a *Accounts) List() []Account {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	accounts := make([]Account, 0, len(a.Accounts))
+	for _, account := range a.Accounts {
+		accounts = append(accounts, *account)
+	}
+
+	return accounts
+}
+
+// CurrentNamespace returns the namespace of the current account
+func (a *Accounts) CurrentNamespace() string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.Current
+}
+
+// SetCurrent makes the account of given namespace the current one
+func (a *Accounts) SetCurrent(namespace string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.Current = namespace
+}
+
+// Merging returns whether the followed streams of every account are displayed
+func (a *Accounts) Merging() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.Merge
+}
+
+// SetMerge sets whether the followed streams of every account are displayed
+func (a *Accounts) SetMerge(merge bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.Merge = merge
+}
+
+// Set adds or updates the account of given namespace
+func (a *Accounts) Set(account Account) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, v := range a.Accounts {
+		if v.Namespace == account.Namespace {
+			*v = account
+			return
+		}
+	}
+
+	a.Accounts = append(a.Accounts, &account)
+}
+
+// Remove removes the account of given namespace
+func (a *Accounts) Remove(namespace string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	accounts := make([]*Account, 0, len(a.Accounts))
+	for _, v := range a.Accounts {
+		if v.Namespace != namespace {
+			accounts = append(accounts, v)
+		}
+	}
+
+	a.Accounts = accounts
+}
+
+// NewNamespace returns a namespace not used by any account
+func (a *Accounts) NewNamespace() string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for i := 1; ; i++ {
+		namespace := fmt.Sprintf("account-%d", i)
+		used := false
+		for _, v := range a.Accounts {
+			used = used || v.Namespace == namespace
+		}
+
+		if !used {
+			return namespace
+		}
+	}
+}
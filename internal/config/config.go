@@ -12,8 +12,99 @@ const (
 	configDirectoryName = "Twitch Clip"
 )
 
+// Token stores
+const (
+	TokenStoreFile      = "file"      // file only readable by the current user
+	TokenStoreKeyring   = "keyring"   // Secret Service keyring
+	TokenStoreEncrypted = "encrypted" // file encrypted with a passphrase
+)
+
 type Config struct {
 	Notifications []string `json:"notifications,omitempty" yaml:"notifications,flow"`
+
+	// MaxStreams caps the number of followed live streams listed. Defaults to 1000 when unset.
+	MaxStreams int `json:"max_streams,omitempty" yaml:"max_streams,omitempty"`
+
+	// OpenChat opens the chat of live streams in its own window next to the player
+	OpenChat bool `json:"open_chat,omitempty" yaml:"open_chat,omitempty"`
+
+	// ChatLogin reads chats as the logged in user instead of anonymously. It requires logging in again once.
+	ChatLogin bool `json:"chat_login,omitempty" yaml:"chat_login,omitempty"`
+
+	// ChatAlerts notifies chat messages of live followed channels matching its rules
+	ChatAlerts ChatAlerts `json:"chat_alerts,omitempty" yaml:"chat_alerts,omitempty"`
+
+	// CacheMaxSize is the maximum size of the images cache on disk, in MB. Defaults to 20 when unset.
+	CacheMaxSize int `json:"cache_max_size,omitempty" yaml:"cache_max_size,omitempty"`
+
+	// CacheMaxDays is the maximum age of cached images, in days. Defaults to 30 when unset.
+	CacheMaxDays int `json:"cache_max_days,omitempty" yaml:"cache_max_days,omitempty"`
+
+	// DeviceCodeLogin logs in by entering a code on any device instead of a browser on this machine
+	DeviceCodeLogin bool `json:"device_code_login,omitempty" yaml:"device_code_login,omitempty"`
+
+	// DisableEventSub only polls followed streams instead of receiving changes as they happen
+	DisableEventSub bool `json:"disable_eventsub,omitempty" yaml:"disable_eventsub,omitempty"`
+
+	// GroupByCategory displays live streams in a submenu per category
+	GroupByCategory bool `json:"group_by_category,omitempty" yaml:"group_by_category,omitempty"`
+
+	// Hide matches streams never displayed, e.g. mature content on a shared machine
+	Hide StreamFilter `json:"hide,omitempty" yaml:"hide,omitempty"`
+
+	// Mute matches streams displayed without notification
+	Mute StreamFilter `json:"mute,omitempty" yaml:"mute,omitempty"`
+
+	// Reminders lists the streamers whose scheduled streams are notified before they start
+	Reminders []string `json:"reminders,omitempty" yaml:"reminders,flow,omitempty"`
+
+	// ReminderMinutes is how long before a scheduled stream starts the reminder is sent. Defaults to 10 when unset.
+	ReminderMinutes int `json:"reminder_minutes,omitempty" yaml:"reminder_minutes,omitempty"`
+
+	// ResumeVideos starts past broadcasts and highlights where they were last stopped, with players reporting it (MPV)
+	ResumeVideos bool `json:"resume_videos,omitempty" yaml:"resume_videos,omitempty"`
+
+	// TokenStore is where the Twitch session is saved: file (default), keyring or encrypted
+	TokenStore string `json:"token_store,omitempty" yaml:"token_store,omitempty"`
+}
+
+// ChatAlerts are the rules of chat messages notified
+type ChatAlerts struct {
+	// Mentions notifies messages containing the username of the logged in user
+	Mentions bool `json:"mentions,omitempty" yaml:"mentions,omitempty"`
+
+	// Keywords are regular expressions notified when found in a message, e.g. (?i)giveaway
+	Keywords []string `json:"keywords,omitempty" yaml:"keywords,flow,omitempty"`
+
+	// Channels restricts alerts to these channel logins. Every live followed channel is watched when empty.
+	Channels []string `json:"channels,omitempty" yaml:"channels,flow,omitempty"`
+
+	// DisabledChannels lists channel logins never watched
+	DisabledChannels []string `json:"disabled_channels,omitempty" yaml:"disabled_channels,flow,omitempty"`
+
+	// MaxPerMinute caps the number of alerts notified each minute. Defaults to 3 when unset.
+	MaxPerMinute int `json:"max_per_minute,omitempty" yaml:"max_per_minute,omitempty"`
+}
+
+// Enabled returns whether any rule is set
+func (c ChatAlerts) Enabled() bool {
+	return c.Mentions || len(c.Keywords) > 0
+}
+
+// StreamFilter matches streams having any of its tags, labels or languages, or marked as mature
+type StreamFilter struct {
+	// Tags are compared case-insensitively
+	Tags []string `json:"tags,omitempty" yaml:"tags,flow,omitempty"`
+
+	// Labels are content classification label IDs:
+	// DrugsIntoxication, Gambling, MatureGame, ProfanityVulgarity, SexualThemes or ViolentGraphic
+	Labels []string `json:"labels,omitempty" yaml:"labels,flow,omitempty"`
+
+	// Mature matches streams intended for mature audiences
+	Mature bool `json:"mature,omitempty" yaml:"mature,omitempty"`
+
+	// Languages are ISO 639-1 codes, e.g. en
+	Languages []string `json:"languages,omitempty" yaml:"languages,flow,omitempty"`
 }
 
 func defaultConfig() *Config {
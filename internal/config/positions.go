@@ -0,0 +1,91 @@
+package config
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	positionsFileName = "positions.yaml"
+	maxPositions      = 200 // most recently watched videos remembered
+)
+
+// Positions remembers where the user stopped watching videos. It is saved by the app, not edited by the user.
+type Positions struct {
+	Videos map[string]*Position `yaml:"videos"` // by video ID
+
+	mutex sync.Mutex
+	path  string // file the positions are saved to, empty when the config directory is unknown
+}
+
+// Position is where a video was stopped
+type Position struct {
+	Seconds   int       `yaml:"seconds"`
+	UpdatedAt time.Time `yaml:"updated_at"`
+}
+
+// LoadPositions loads the positions stored on disk
+func LoadPositions() *Positions {
+	positions := &Positions{Videos: make(map[string]*Position)}
+	positions.path = loadState(positionsFileName, positions)
+	if positions.Videos == nil {
+		positions.Videos = make(map[string]*Position)
+	}
+
+	return positions
+}
+
+// Save writes the positions on disk
+func (p *Positions) Save() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return saveState(p.path, p)
+}
+
+// Get returns where given video was stopped, zero if it was not
+func (p *Positions) Get(videoID string) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if v, ok := p.Videos[videoID]; ok {
+		return time.Duration(v.Seconds) * time.Second
+	}
+
+	return 0
+}
+
+// Set remembers where given video was stopped, forgetting the least recently watched videos
+func (p *Positions) Set(videoID string, position time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.Videos == nil {
+		p.Videos = make(map[string]*Position)
+	}
+
+	p.Videos[videoID] = &Position{Seconds: int(position / time.Second), UpdatedAt: time.Now()}
+	if len(p.Videos) <= maxPositions {
+		return
+	}
+
+	ids := make([]string, 0, len(p.Videos))
+	for id := range p.Videos {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return p.Videos[ids[i]].UpdatedAt.After(p.Videos[ids[j]].UpdatedAt)
+	})
+
+	for _, id := range ids[maxPositions:] {
+		delete(p.Videos, id)
+	}
+}
+
+// Delete forgets given video, once watched until the end
+func (p *Positions) Delete(videoID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.Videos, videoID)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// loadState reads the state file of given name from the config directory into v.
+// It returns the file path, empty when the config directory is unknown.
+func loadState(name string, v interface{}) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Errorf("cannot load %s: %v", name, err)
+		return ""
+	}
+
+	path := filepath.Join(dir, configDirectoryName, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path
+	}
+
+	if err != nil {
+		log.Errorf("cannot load %s: %v", name, err)
+		return path
+	}
+
+	if err := yaml.Unmarshal(data, v); err != nil {
+		log.Errorf("cannot load %s: %v", name, err)
+	}
+
+	return path
+}
+
+// saveState writes v to the state file at path
+func saveState(path string, v interface{}) error {
+	if path == "" {
+		return errors.New("cannot save state: unknown config directory")
+	}
+
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot save %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot save %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot save %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
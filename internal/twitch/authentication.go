@@ -4,16 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
-	"golang.org/x/oauth2/twitch"
-
 	log "github.com/sirupsen/logrus"
 
 	"github.com/pkg/browser"
@@ -23,9 +21,10 @@ import (
 var _ http.RoundTripper = (*transport)(nil)
 
 const (
-	tokenDir   = "Twitch Clip"
-	tokenFile  = "token.dat" // used to store current session token on disk
-	serverAddr = "localhost:7001"
+	tokenDir    = "Twitch Clip"
+	accountsDir = "accounts"  // sessions and caches of additional accounts
+	tokenFile   = "token.dat" // used to store current session token on disk
+	serverAddr  = "localhost:7001"
 )
 
 type transport struct {
@@ -39,74 +38,40 @@ func (t transport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return t.Original.RoundTrip(r)
 }
 
-var (
-	state              string
-	srv                *http.Server                 // server to handle redirect URI
-	authenticationDone = make(chan *http.Client, 1) // notify when callback process is done, we receive the configured http.Client
-	oauth2Config       *oauth2.Config               // carry the entire Twitch oauth2 process
-
-	// ErrInvalidState state configured between request and response
-	ErrInvalidState = errors.New("invalid state coming from Twitch")
-)
+// ErrInvalidState state configured between request and response
+var ErrInvalidState = errors.New("invalid state coming from Twitch")
+
+// browserLogin is an authorization code flow in progress, receiving the session token on its redirect URI.
+// Each login has its own, so clients of several accounts never share any state.
+type browserLogin struct {
+	config  *oauth2.Config
+	store   TokenStore              // where the token is saved once received
+	account string                  // owner of the token being received
+	state   string                  // protects against CSRF
+	srv     *http.Server            // handles the redirect URI
+	done    chan oauth2.TokenSource // receives the session token
+}
 
-// handleUserLogin creates the initial URL request
-// User's browser will be open with this URL
-func handleUserLogin(oauth2Config *oauth2.Config) error {
+// newBrowserLogin returns a login saving the token of account in store
+func newBrowserLogin(config *oauth2.Config, store TokenStore, account string) (*browserLogin, error) {
 	var tokenBytes [255]byte
 	if _, err := rand.Read(tokenBytes[:]); err != nil {
-		return fmt.Errorf("unable to generate random state: %w", err)
-	}
-
-	// save state as global to protect again CSRF
-	state = hex.EncodeToString(tokenBytes[:])
-	u := oauth2Config.AuthCodeURL(state)
-	return browser.OpenURL(u)
-}
-
-// handleOAuth2Callback is a Handler for oauth's 'redirect_uri' endpoint;
-// it validates the state token and retrieves an OAuth token from the request parameters.
-func handleOAuth2Callback(w http.ResponseWriter, r *http.Request) error {
-	log.Debugln("received oauth2 callback")
-	if v := r.FormValue("state"); v != state {
-		return ErrInvalidState
+		return nil, fmt.Errorf("unable to generate random state: %w", err)
 	}
 
-	// Use the custom HTTP client when requesting a token.
-	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, setupHTTPClient(oauth2Config.ClientID))
-	token, err := oauth2Config.Exchange(ctx, r.FormValue("code"))
-	if err != nil {
-		return fmt.Errorf("unable to oauth code: %w", err)
-	}
-
-	// send our configured http.Client
-	authenticationDone <- oauth2Config.Client(ctx, token)
-
-	// Store token on disk
-	go func() {
-		if err := storeTokenOnFile(token); err != nil {
-			log.Errorln(err)
-		}
-	}()
-
-	// Close this web server, we don't need it anymore
-	go func() {
-		time.Sleep(time.Second * 10) // let user see the response
-		log.Debugf("closing web server")
-		if err := srv.Close(); err != nil {
-			log.Errorln(err)
-		}
-	}()
-
-	_, _ = fmt.Fprint(w, "Authentication successful, you can close this tab")
-	return nil
+	return &browserLogin{
+		config:  config,
+		store:   store,
+		account: account,
+		state:   hex.EncodeToString(tokenBytes[:]),
+		done:    make(chan oauth2.TokenSource, 1),
+	}, nil
 }
 
-// configOAuth2Workflow create a oauth2.Config object related to Twitch
-// It will create and start an http.Server to handle redirect URI
-// By default, it will use a free open port for the redirect URI
-// The returned http.Server must be Close() when job is done
+// start serves the redirect URI and opens the user's browser on the Twitch authorization page.
+// forceVerify makes Twitch ask the user to authorize again, so they can pick another account.
 // https://github.com/twitchdev/authentication-go-sample/blob/main/oauth-authorization-code/main.go
-func configOAuth2Workflow() {
+func (l *browserLogin) start(forceVerify bool) error {
 	var errorHandling = func(handler func(http.ResponseWriter, *http.Request) error) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if err := handler(w, r); err != nil {
@@ -118,114 +83,183 @@ func configOAuth2Workflow() {
 		})
 	}
 
-	http.Handle("/", errorHandling(handleOAuth2Callback))
-	srv = &http.Server{
+	mux := http.NewServeMux()
+	mux.Handle("/", errorHandling(l.handleCallback))
+	l.srv = &http.Server{
 		Addr:    serverAddr,
-		Handler: nil, // let use http.DefaultServeMux
+		Handler: mux,
+	}
+
+	// listen now, so a port already in use fails this login
+	listener, err := net.Listen("tcp", serverAddr)
+	if err != nil {
+		return fmt.Errorf("fail to start web server: %w", err)
 	}
 
-	// start server
 	go func() {
-		log.Debugf("starting web server for oauth2 callblack at %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Debugf("starting web server for oauth2 callblack at %s", l.srv.Addr)
+		if err := l.srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorf("fail to start web server: %s", err)
 		}
 	}()
-}
 
-func getToken(ctx context.Context, config *Config) (*http.Client, error) {
-	oauth2Config = &oauth2.Config{
-		ClientID:     config.ClientID,
-		ClientSecret: config.ClientSecret,
-		Scopes:       []string{"user:read:follows"},
-		Endpoint:     twitch.Endpoint,
-		RedirectURL:  "http://" + serverAddr,
+	var opts []oauth2.AuthCodeOption
+	if forceVerify {
+		opts = append(opts, oauth2.SetAuthURLParam("force_verify", "true"))
 	}
 
-	// Retrieve token from disk
-	token, err := retrieveTokenOnFile()
-	if err == nil {
-		// We have our token on disk, use it!
-		log.Debugln("using token from disk")
-		c := context.WithValue(context.Background(), oauth2.HTTPClient, setupHTTPClient(config.ClientID))
-		return oauth2Config.Client(c, token), nil
+	if err := browser.OpenURL(l.config.AuthCodeURL(l.state, opts...)); err != nil {
+		l.close()
+		return err
 	}
 
-	// No token found, we need a new one
-	log.Warningln(err)
-
-	// setup server
-	configOAuth2Workflow()
-
-	// Start the oauth workflow
-	if err := handleUserLogin(oauth2Config); err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	// Wait for authentication process
+// wait returns the session token once received, then stops the web server
+func (l *browserLogin) wait(ctx context.Context) (oauth2.TokenSource, error) {
 	log.Debugln("waiting for authentication callback")
 	select {
-	case v := <-authenticationDone:
+	case v := <-l.done:
+		go func() {
+			time.Sleep(time.Second * 10) // let user see the response
+			l.close()
+		}()
+
 		return v, nil
 	case <-ctx.Done():
+		l.close()
 		return nil, ctx.Err()
 	}
 }
 
-// setupHTTPClient return our custom HTTP client
-func setupHTTPClient(clientID string) *http.Client {
-	return &http.Client{
-		Timeout:   time.Second * 10, // global timeout
-		Transport: &transport{http.DefaultTransport, clientID},
+// close stops the web server
+func (l *browserLogin) close() {
+	log.Debugf("closing web server")
+	if err := l.srv.Close(); err != nil {
+		log.Errorln(err)
 	}
 }
 
-// storeTokenOnFile save given token to file
-func storeTokenOnFile(token *oauth2.Token) error {
-	configDir, _ := os.UserConfigDir()
-	configDir = filepath.Join(configDir, tokenDir)
+// handleCallback is a Handler for oauth's 'redirect_uri' endpoint;
+// it validates the state token and retrieves an OAuth token from the request parameters.
+func (l *browserLogin) handleCallback(w http.ResponseWriter, r *http.Request) error {
+	log.Debugln("received oauth2 callback")
+	if v := r.FormValue("state"); v != l.state {
+		return ErrInvalidState
+	}
 
-	// crete config directory
-	if _, err := os.Stat(configDir); errors.Is(err, os.ErrNotExist) {
-		log.Tracef("creating %s", configDir)
-		_ = os.Mkdir(configDir, 0755)
+	// Use the custom HTTP client when requesting a token.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, setupHTTPClient(l.config.ClientID))
+	token, err := l.config.Exchange(ctx, r.FormValue("code"))
+	if err != nil {
+		return fmt.Errorf("unable to oauth code: %w", err)
+	}
+
+	// Store token
+	if err := l.store.Save(token); err != nil {
+		log.Errorln(err)
+	}
+
+	// send our session token, once
+	select {
+	case l.done <- newPersistingTokenSource(ctx, l.config, l.store, l.account, token):
+	default:
+	}
+
+	_, _ = fmt.Fprint(w, "Authentication successful, you can close this tab")
+	return nil
+}
+
+// getToken returns the session token saved in store if reuse is set, or logs the user in granting given scopes
+func getToken(ctx context.Context, config *Config, store TokenStore, scopes []string, reuse bool) (oauth2.TokenSource, error) {
+	oauth2Config := config.oauth2Config(scopes)
+
+	// Retrieve stored token
+	c := context.WithValue(context.Background(), oauth2.HTTPClient, setupHTTPClient(config.ClientID))
+	token, err := store.Load()
+	if !reuse {
+		err = errors.New("asking for new twitch permissions")
+	}
+
+	if err == nil {
+		// We have our token, use it!
+		log.Debugln("using stored token")
+		return newPersistingTokenSource(c, oauth2Config, store, config.Account, token), nil
+	}
+
+	if config.DisableLogin {
+		return nil, fmt.Errorf("%w: %s", ErrLoggedOut, err)
+	}
+
+	// No token found, we need a new one
+	log.Warningln(err)
+
+	if config.useDeviceCode() {
+		token, err := getTokenWithDeviceCode(ctx, setupHTTPClient(config.ClientID), config.deviceURL(), oauth2Config, config.DeviceCodePrompt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := store.Save(token); err != nil {
+			log.Errorln(err)
+		}
+
+		return newPersistingTokenSource(c, oauth2Config, store, config.Account, token), nil
 	}
 
-	tokenFilePath := filepath.Join(configDir, tokenFile)
-	// If the file doesn't exist, create it, or append to the file
-	log.Tracef("opening %s", tokenFilePath)
-	f, err := os.OpenFile(tokenFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	// Start the oauth workflow, the user's browser is opened
+	login, err := newBrowserLogin(oauth2Config, store, config.Account)
 	if err != nil {
-		return fmt.Errorf("unable to open token file: %w", err)
+		return nil, err
+	}
+
+	if err := login.start(config.Account != ""); err != nil {
+		return nil, err
 	}
-	defer f.Close()
 
-	log.Debugf("writing token content to %s", tokenFilePath)
-	return json.NewEncoder(f).Encode(token)
+	return login.wait(ctx)
 }
 
-// retrieveTokenOnFile return token store on disk
-func retrieveTokenOnFile() (*oauth2.Token, error) {
-	configDir, _ := os.UserConfigDir()
-	configDir = filepath.Join(configDir, tokenDir)
+// newHTTPClient returns an http.Client authenticated with given token.
+// A persisting token source already caches its token, it is used as is so that
+// a token refreshed after a 401 is sent right away instead of the rejected one.
+func newHTTPClient(clientID string, tokenSource oauth2.TokenSource) *http.Client {
+	if _, ok := tokenSource.(*persistingTokenSource); !ok {
+		tokenSource = oauth2.ReuseTokenSource(nil, tokenSource)
+	}
 
-	// If folder does not exist, return error
-	if _, err := os.Stat(configDir); errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("cannot find token file in %s", configDir)
+	return &http.Client{
+		Transport: &oauth2.Transport{Source: tokenSource, Base: setupHTTPClient(clientID).Transport},
 	}
+}
 
-	tokenFilePath := filepath.Join(configDir, tokenFile)
-	log.Tracef("opening %s", tokenFilePath)
-	f, err := os.OpenFile(tokenFilePath, os.O_RDONLY, 0644)
+// setupHTTPClient return our custom HTTP client
+func setupHTTPClient(clientID string) *http.Client {
+	return &http.Client{
+		Timeout:   time.Second * 10, // global timeout
+		Transport: &transport{http.DefaultTransport, clientID},
+	}
+}
+
+// tokenDirectory returns the directory containing the token file of given account, creating it if needed
+func tokenDirectory(account string) (string, error) {
+	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("cannot open token file: %w", err)
+		return "", fmt.Errorf("unable to find config directory: %w", err)
 	}
 
-	token := new(oauth2.Token)
-	log.Tracef("reading %s", tokenFilePath)
-	if err := json.NewDecoder(f).Decode(token); err != nil {
-		return nil, fmt.Errorf("cannot read token file: %w", err)
+	configDir = filepath.Join(configDir, tokenDir)
+	if account != "" {
+		configDir = filepath.Join(configDir, accountsDir, account)
+	}
+
+	if _, err := os.Stat(configDir); errors.Is(err, os.ErrNotExist) {
+		log.Tracef("creating %s", configDir)
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			return "", fmt.Errorf("unable to create config directory: %w", err)
+		}
 	}
 
-	return token, nil
+	return configDir, nil
 }
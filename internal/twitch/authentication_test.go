@@ -0,0 +1,70 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func Test_browserLogin_handleCallback(t *testing.T) {
+	useTemporaryConfigDir(t)
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"access-%s","refresh_token":"refresh","token_type":"bearer","expires_in":3600}`, r.PostFormValue("code"))
+	}))
+	defer tokenServer.Close()
+
+	config := &oauth2.Config{ClientID: "foo", Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams}}
+	foo, err := newBrowserLogin(config, NewAccountFileTokenStore("foo"), "foo")
+	if err != nil {
+		t.Fatalf("newBrowserLogin() error = %v", err)
+	}
+	bar, err := newBrowserLogin(config, NewAccountFileTokenStore("bar"), "bar")
+	if err != nil {
+		t.Fatalf("newBrowserLogin() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		login   *browserLogin
+		state   string
+		wantErr error
+	}{
+		{
+			name:    "Expected state of another login to be refused",
+			login:   bar,
+			state:   foo.state,
+			wantErr: ErrInvalidState,
+		},
+		{
+			name:  "Expected token saved in the store of its account",
+			login: foo,
+			state: foo.state,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{"state": {tt.state}, "code": {tt.login.account}}
+			r := httptest.NewRequest(http.MethodGet, "/?"+q.Encode(), nil)
+			if err := tt.login.handleCallback(httptest.NewRecorder(), r); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			token, err := (<-tt.login.done).Token()
+			if err != nil || token.AccessToken != "access-"+tt.login.account {
+				t.Errorf("handleCallback() got = %v, %v, want access-%s", token, err, tt.login.account)
+			}
+			if stored, err := tt.login.store.Load(); err != nil || stored.AccessToken != token.AccessToken {
+				t.Errorf("stored token = %v, %v, want %v", stored, err, token.AccessToken)
+			}
+		})
+	}
+}
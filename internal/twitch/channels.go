@@ -0,0 +1,72 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+const (
+	channelsURI         = "/channels"
+	followedChannelsURI = "/channels/followed"
+
+	maxFollowedChannels = 5000 // maximum number of followed channels read across pages
+)
+
+var _ ChannelsI = (*channelsClient)(nil)
+
+// FollowedChannel describes a channel followed by the authenticated user
+type FollowedChannel struct {
+	BroadcasterID    string    `json:"broadcaster_id"`
+	BroadcasterLogin string    `json:"broadcaster_login"`
+	BroadcasterName  string    `json:"broadcaster_name"`
+	FollowedAt       time.Time `json:"followed_at"`
+}
+
+// Channel describes the information of a channel
+type Channel struct {
+	BroadcasterID       string   `json:"broadcaster_id"`
+	BroadcasterLogin    string   `json:"broadcaster_login"`
+	BroadcasterName     string   `json:"broadcaster_name"`
+	BroadcasterLanguage string   `json:"broadcaster_language"`
+	GameID              string   `json:"game_id"`
+	GameName            string   `json:"game_name"`
+	Title               string   `json:"title"`
+	Delay               int      `json:"delay"`
+	Tags                []string `json:"tags"`
+	IsBrandedContent    bool     `json:"is_branded_content"`
+
+	// ContentClassificationLabels are the IDs of the labels set by the broadcaster, e.g. MatureGame or Gambling
+	ContentClassificationLabels []string `json:"content_classification_labels"`
+}
+
+type ChannelsI interface {
+	// Get returns the information of the channels of given broadcaster IDs, requested by batches of 100.
+	// https://dev.twitch.tv/docs/api/reference/#get-channel-information
+	Get(ctx context.Context, broadcasterID ...string) ([]*Channel, error)
+
+	// GetFollowed returns every channel the authenticated user follows, most recently followed first.
+	// https://dev.twitch.tv/docs/api/reference/#get-followed-channels
+	GetFollowed(ctx context.Context) ([]*FollowedChannel, error)
+}
+
+type channelsClient struct {
+	c *Client
+}
+
+func (c *channelsClient) Get(ctx context.Context, broadcasterID ...string) ([]*Channel, error) {
+	return getBatches[*Channel](ctx, c.c, c.c.baseURL+channelsURI, "broadcaster_id", broadcasterID)
+}
+
+func (c *channelsClient) GetFollowed(ctx context.Context) ([]*FollowedChannel, error) {
+	me := c.c.Users.Me()
+	if me == nil {
+		return nil, ErrLoggedOut
+	}
+
+	q := make(url.Values)
+	q.Set("user_id", me.ID)
+	return list(ctx, c.c, c.c.baseURL+followedChannelsURI, q, maxFollowedChannels, func(f *FollowedChannel) string {
+		return f.BroadcasterID
+	})
+}
@@ -0,0 +1,37 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_channelsClient_Get(t *testing.T) {
+	srv := newTestServer(t)
+	var ids []string
+	for i := 0; i < 150; i++ {
+		id := strconv.Itoa(1000 + i)
+		srv.AddUser(&twitchtest.User{ID: id, Login: "user" + id})
+		ids = append(ids, id)
+	}
+	srv.AddChannel(&twitchtest.Channel{BroadcasterID: "1000", ContentClassificationLabels: []string{"MatureGame"}})
+
+	c := newTestClient(t, srv)
+	got, err := c.Channels.Get(context.Background(), ids...)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if len(got) != len(ids) {
+		t.Fatalf("Get() got %d channels, want %d", len(got), len(ids))
+	}
+	if want := []string{"MatureGame"}; !reflect.DeepEqual(got[0].ContentClassificationLabels, want) {
+		t.Errorf("Get() labels = %v, want %v", got[0].ContentClassificationLabels, want)
+	}
+	if requests := srv.Requests("/channels"); requests != 2 {
+		t.Errorf("Get() requests = %v, want %v", requests, 2)
+	}
+}
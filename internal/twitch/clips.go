@@ -0,0 +1,57 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+const clipsURI = "/clips"
+
+var _ ClipsI = (*clipsClient)(nil)
+
+// Clip describes a Twitch clip
+type Clip struct {
+	BroadcasterID   string    `json:"broadcaster_id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatedAt       time.Time `json:"created_at"`
+	CreatorID       string    `json:"creator_id"`
+	CreatorName     string    `json:"creator_name"`
+	Duration        float64   `json:"duration"` // seconds
+	EmbedURL        string    `json:"embed_url"`
+	GameID          string    `json:"game_id"`
+	ID              string    `json:"id"`
+	Language        string    `json:"language"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	Title           string    `json:"title"`
+	URL             string    `json:"url"`
+	VideoID         string    `json:"video_id"`
+	ViewCount       int       `json:"view_count"`
+}
+
+type ClipsI interface {
+	// GetByBroadcaster returns at most max clips of given broadcaster created between startedAt and endedAt,
+	// most viewed first. A zero startedAt or endedAt leaves the window open on that side.
+	// https://dev.twitch.tv/docs/api/reference/#get-clips
+	GetByBroadcaster(ctx context.Context, broadcasterID string, startedAt, endedAt time.Time, max int) ([]*Clip, error)
+}
+
+type clipsClient struct {
+	c *Client
+}
+
+func (c *clipsClient) GetByBroadcaster(ctx context.Context, broadcasterID string, startedAt, endedAt time.Time, max int) ([]*Clip, error) {
+	q := make(url.Values)
+	q.Set("broadcaster_id", broadcasterID)
+	if !startedAt.IsZero() {
+		q.Set("started_at", startedAt.UTC().Format(time.RFC3339))
+	}
+
+	if !endedAt.IsZero() {
+		q.Set("ended_at", endedAt.UTC().Format(time.RFC3339))
+	}
+
+	return list(ctx, c.c, c.c.baseURL+clipsURI, q, max, func(clip *Clip) string {
+		return clip.ID
+	})
+}
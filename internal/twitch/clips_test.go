@@ -0,0 +1,74 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_clipsClient_GetByBroadcaster(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		srv.AddClip(&twitchtest.Clip{ID: "c" + strconv.Itoa(i), BroadcasterID: "2", CreatedAt: now.Add(-time.Hour * 24 * time.Duration(i))})
+	}
+	srv.AddClip(&twitchtest.Clip{ID: "other", BroadcasterID: "3", CreatedAt: now})
+	srv.SetPageSize(5)
+	c := newTestClient(t, srv)
+
+	type args struct {
+		broadcasterID string
+		startedAt     time.Time
+		endedAt       time.Time
+		max           int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string // clip IDs
+		wantErr bool
+	}{
+		{
+			name: "Expected clips of broadcaster across pages",
+			args: args{broadcasterID: "2", max: 12},
+			want: []string{"c0", "c1", "c2", "c3", "c4", "c5", "c6", "c7", "c8", "c9", "c10", "c11"},
+		},
+		{
+			name: "Expected clips within window",
+			args: args{broadcasterID: "2", startedAt: now.Add(-time.Hour * 24 * 7), endedAt: now.Add(-time.Hour * 24 * 5), max: 10},
+			want: []string{"c5", "c6", "c7"},
+		},
+		{
+			name: "Expected no clip",
+			args: args{broadcasterID: "4", max: 10},
+			want: []string{},
+		},
+		{
+			name:    "Expected error without broadcaster",
+			args:    args{max: 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &clipsClient{c: c}
+			got, err := cc.GetByBroadcaster(context.Background(), tt.args.broadcasterID, tt.args.startedAt, tt.args.endedAt, tt.args.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetByBroadcaster() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			ids := make([]string, 0, len(got))
+			for _, clip := range got {
+				ids = append(ids, clip.ID)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("GetByBroadcaster() got = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
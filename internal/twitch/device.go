@@ -0,0 +1,203 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// Twitch error messages while polling for the device token
+	deviceAuthorizationPending = "authorization_pending"
+	deviceSlowDown             = "slow_down"
+	deviceInvalidCode          = "invalid device code"
+	deviceAccessDenied         = "access_denied"
+)
+
+var (
+	// ErrDeviceCodeExpired the user did not approve the device in time
+	ErrDeviceCodeExpired = errors.New("device code expired before user approval")
+
+	// ErrDeviceAccessDenied the user refused to authorize the device
+	ErrDeviceAccessDenied = errors.New("user denied device authorization")
+)
+
+// DeviceCode describes the code a user must enter at VerificationURI to log in this device
+// https://dev.twitch.tv/docs/authentication/getting-tokens-oauth/#device-code-grant-flow
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"` // seconds
+	Interval        int    `json:"interval"`   // seconds between two polls
+}
+
+// deviceTokenResponse is the Twitch token response, scope is an array instead of a string
+type deviceTokenResponse struct {
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int      `json:"expires_in"`
+	TokenType    string   `json:"token_type"`
+	Scope        []string `json:"scope"`
+}
+
+// getTokenWithDeviceCode runs the Device Code Grant flow as a public client.
+// Each issued code is sent to prompt, a new code is requested when the previous one expires.
+// prompt is called with nil once the flow ends.
+func getTokenWithDeviceCode(ctx context.Context, httpClient *http.Client, deviceURL string, oauth2Config *oauth2.Config, prompt func(*DeviceCode)) (*oauth2.Token, error) {
+	if prompt != nil {
+		defer prompt(nil)
+	}
+
+	for {
+		code, err := requestDeviceCode(ctx, httpClient, deviceURL, oauth2Config)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Infof("to log in, visit %s and enter code %s", code.VerificationURI, code.UserCode)
+		if prompt != nil {
+			prompt(code)
+		}
+
+		token, err := pollDeviceToken(ctx, httpClient, oauth2Config, code)
+		if errors.Is(err, ErrDeviceCodeExpired) {
+			log.Warningln(err)
+			continue // ask for a new one
+		}
+
+		return token, err
+	}
+}
+
+// requestDeviceCode starts the Device Code Grant flow
+func requestDeviceCode(ctx context.Context, httpClient *http.Client, deviceURL string, oauth2Config *oauth2.Config) (*DeviceCode, error) {
+	form := url.Values{
+		"client_id": {oauth2Config.ClientID},
+		"scopes":    {strings.Join(oauth2Config.Scopes, " ")},
+	}
+
+	body, status, err := postForm(ctx, httpClient, deviceURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request device code: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, readError(body)
+	}
+
+	code := new(DeviceCode)
+	if err := json.Unmarshal(body, code); err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	return code, nil
+}
+
+// pollDeviceToken waits for the user to approve the given code and returns the issued token
+func pollDeviceToken(ctx context.Context, httpClient *http.Client, oauth2Config *oauth2.Config, code *DeviceCode) (*oauth2.Token, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Second * 5
+	}
+
+	expired := time.After(time.Duration(code.ExpiresIn) * time.Second)
+	form := url.Values{
+		"client_id":   {oauth2Config.ClientID},
+		"scopes":      {strings.Join(oauth2Config.Scopes, " ")},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {deviceGrantType},
+	}
+
+	log.Debugln("waiting for device authorization")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-expired:
+			return nil, ErrDeviceCodeExpired
+		case <-time.After(interval):
+		}
+
+		body, status, err := postForm(ctx, httpClient, oauth2Config.Endpoint.TokenURL, form)
+		if err != nil {
+			log.Warningf("unable to poll device token: %s", err)
+			continue // network may come back before expiration
+		}
+
+		if status == http.StatusOK {
+			data := new(deviceTokenResponse)
+			if err := json.Unmarshal(body, data); err != nil {
+				return nil, fmt.Errorf("unable to read response body: %w", err)
+			}
+
+			return &oauth2.Token{
+				AccessToken:  data.AccessToken,
+				TokenType:    data.TokenType,
+				RefreshToken: data.RefreshToken,
+				Expiry:       time.Now().Add(time.Duration(data.ExpiresIn) * time.Second),
+			}, nil
+		}
+
+		e := new(Error)
+		if err := json.Unmarshal(body, e); err != nil {
+			return nil, fmt.Errorf("twitch error: %s", body)
+		}
+
+		switch e.Message {
+		case deviceAuthorizationPending:
+			continue
+		case deviceSlowDown:
+			interval += time.Second * 5
+		case deviceInvalidCode:
+			return nil, ErrDeviceCodeExpired
+		case deviceAccessDenied:
+			return nil, ErrDeviceAccessDenied
+		default:
+			return nil, e
+		}
+	}
+}
+
+// postForm sends given form and returns the response body and status code
+func postForm(ctx context.Context, httpClient *http.Client, u string, form url.Values) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, resp.StatusCode, nil
+}
+
+// readError returns the Twitch error contained in body if any
+func readError(body []byte) error {
+	e := new(Error)
+	if err := json.Unmarshal(body, e); err == nil {
+		return e
+	}
+
+	return fmt.Errorf("twitch error: %s", body)
+}
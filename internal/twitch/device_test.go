@@ -0,0 +1,72 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func Test_pollDeviceToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string // successive token endpoint responses, the last one is repeated
+		want      string
+		wantErr   error
+	}{
+		{
+			name: "Expected after pending authorization",
+			responses: []string{
+				`{"status":400,"message":"authorization_pending"}`,
+				`{"access_token":"foo","refresh_token":"bar","expires_in":3600,"scope":["user:read:follows"],"token_type":"bearer"}`,
+			},
+			want: "foo",
+		},
+		{
+			name:      "Expected expired code",
+			responses: []string{`{"status":400,"message":"invalid device code"}`},
+			wantErr:   ErrDeviceCodeExpired,
+		},
+		{
+			name:      "Expected access denied",
+			responses: []string{`{"status":400,"message":"access_denied"}`},
+			wantErr:   ErrDeviceAccessDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.FormValue("device_code") != "code" || r.FormValue("grant_type") != deviceGrantType {
+					t.Errorf("unexpected form %v", r.Form)
+				}
+
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				if n >= len(tt.responses) {
+					n = len(tt.responses) - 1
+				}
+
+				if n < len(tt.responses)-1 || tt.wantErr != nil {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				_, _ = w.Write([]byte(tt.responses[n]))
+			}))
+			defer srv.Close()
+
+			config := &oauth2.Config{ClientID: "foo", Endpoint: oauth2.Endpoint{TokenURL: srv.URL}}
+			code := &DeviceCode{DeviceCode: "code", ExpiresIn: 60, Interval: 1}
+
+			got, err := pollDeviceToken(context.Background(), srv.Client(), config, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pollDeviceToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.AccessToken != tt.want {
+				t.Errorf("pollDeviceToken() got = %v, want %v", got.AccessToken, tt.want)
+			}
+		})
+	}
+}
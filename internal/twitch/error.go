@@ -1,8 +1,36 @@
 package twitch
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
 
-var _ error = (*Error)(nil)
+var (
+	_ error = (*Error)(nil)
+	_ error = (*RateLimitError)(nil)
+)
+
+// Errors matched by errors.Is against the Error and RateLimitError returned by Twitch calls
+var (
+	// ErrUnauthorized is matched by 401 responses, once the session has been renewed and the request replayed
+	ErrUnauthorized = errors.New("twitch request unauthorized")
+
+	// ErrForbidden is matched by 403 responses
+	ErrForbidden = errors.New("twitch request forbidden")
+
+	// ErrNotFound is matched by 404 responses
+	ErrNotFound = errors.New("twitch resource not found")
+
+	// ErrRateLimited is matched by 429 responses
+	ErrRateLimited = errors.New("twitch rate limit exceeded")
+
+	// ErrServer is matched by 5xx responses
+	ErrServer = errors.New("twitch server error")
+)
 
 // Error describes a Twitch error
 type Error struct {
@@ -14,3 +42,58 @@ type Error struct {
 func (e Error) Error() string {
 	return fmt.Sprintf("twitch error %d %s: %s", e.Status, e.Err, e.Message)
 }
+
+// Is makes errors.Is match the error of its status, e.g. ErrNotFound
+func (e Error) Is(target error) bool {
+	switch {
+	case e.Status == http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case e.Status == http.StatusForbidden:
+		return target == ErrForbidden
+	case e.Status == http.StatusNotFound:
+		return target == ErrNotFound
+	case e.Status == http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case e.Status >= http.StatusInternalServerError:
+		return target == ErrServer
+	}
+
+	return false
+}
+
+// RateLimitError is returned when the Helix rate limit is still exceeded after every retry
+type RateLimitError struct {
+	Reset time.Time // when the bucket is refilled
+	Err   error     // Twitch error, if any
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("twitch rate limit exceeded until %s: %s", e.Reset.Format(time.RFC3339), e.Err)
+}
+
+func (e RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is match ErrRateLimited
+func (e RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// readStatusError returns the Twitch error of a response with given status, even if its body is not one
+func readStatusError(status int, body []byte) error {
+	e := new(Error)
+	if err := json.Unmarshal(body, e); err != nil {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if e.Status == 0 {
+		e.Status = status
+	}
+
+	if e.Err == "" {
+		e.Err = http.StatusText(e.Status)
+	}
+
+	return e
+}
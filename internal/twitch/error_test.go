@@ -1,6 +1,11 @@
 package twitch
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
 
 func TestError_Error(t *testing.T) {
 	type fields struct {
@@ -36,3 +41,109 @@ func TestError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitError_Error(t *testing.T) {
+	type fields struct {
+		Reset time.Time
+		Err   error
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   string
+	}{
+		{
+			name: "expected",
+			fields: fields{
+				Reset: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+				Err:   Error{Err: "Too Many Requests", Status: 429, Message: "foo"},
+			},
+			want: "twitch rate limit exceeded until 2021-01-02T03:04:05Z: twitch error 429 Too Many Requests: foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := RateLimitError{
+				Reset: tt.fields.Reset,
+				Err:   tt.fields.Err,
+			}
+			if got := e.Error(); got != tt.want {
+				t.Errorf("Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "Expected unauthorized",
+			err:  &Error{Status: 401},
+			want: ErrUnauthorized,
+		},
+		{
+			name: "Expected forbidden",
+			err:  &Error{Status: 403},
+			want: ErrForbidden,
+		},
+		{
+			name: "Expected not found once wrapped",
+			err:  fmt.Errorf("unable to get schedule: %w", &Error{Status: 404}),
+			want: ErrNotFound,
+		},
+		{
+			name: "Expected rate limited",
+			err:  &RateLimitError{Err: errors.New("foo")},
+			want: ErrRateLimited,
+		},
+		{
+			name: "Expected server error",
+			err:  Error{Status: 503},
+			want: ErrServer,
+		},
+	}
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrServer}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, target := range sentinels {
+				if got := errors.Is(tt.err, target); got != (target == tt.want) {
+					t.Errorf("Is(%v) got = %v, want %v", target, got, target == tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_readStatusError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   Error
+	}{
+		{
+			name:   "Expected Twitch error",
+			status: 404,
+			body:   `{"error":"Not Found","status":404,"message":"foo"}`,
+			want:   Error{Err: "Not Found", Status: 404, Message: "foo"},
+		},
+		{
+			name:   "Expected status of a body which is not an error",
+			status: 502,
+			body:   "bad gateway\n",
+			want:   Error{Err: "Bad Gateway", Status: 502, Message: "bad gateway"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Error
+			if !errors.As(readStatusError(tt.status, []byte(tt.body)), &got) || *got != tt.want {
+				t.Errorf("readStatusError() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package twitch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultEventSubURL    = "wss://eventsub.wss.twitch.tv/ws"
+	eventSubscriptionsURI = "/eventsub/subscriptions"
+
+	eventSubWelcomeTimeout = time.Second * 10 // Twitch closes the connection if we do not subscribe within 10s
+	eventSubKeepaliveGrace = time.Second * 5  // extra time given to a keepalive before considering the connection dead
+	eventSubMinBackoff     = time.Second
+	eventSubMaxBackoff     = time.Minute * 2
+	eventSubSeenMessages   = 1000 // number of message IDs remembered to drop duplicates
+)
+
+// EventSub WebSocket message types
+// https://dev.twitch.tv/docs/eventsub/websocket-reference/
+const (
+	eventSubWelcome      = "session_welcome"
+	eventSubKeepalive    = "session_keepalive"
+	eventSubNotification = "notification"
+	eventSubReconnect    = "session_reconnect"
+	eventSubRevocation   = "revocation"
+)
+
+// Event types sent by EventSubI.Listen
+const (
+	// EventConnected is sent each time a new session is subscribed. Events may have been missed before it.
+	EventConnected     = "connected"
+	EventStreamOnline  = "stream.online"
+	EventStreamOffline = "stream.offline"
+	EventChannelUpdate = "channel.update"
+)
+
+var _ EventSubI = (*eventSubClient)(nil)
+
+// ErrUnexpectedEventSubMessage the server did not follow the EventSub WebSocket protocol
+var ErrUnexpectedEventSubMessage = errors.New("unexpected eventsub message")
+
+// eventSubSubscriptions are created for each watched broadcaster, with their version
+var eventSubSubscriptions = []struct {
+	Type    string
+	Version string
+}{
+	{EventStreamOnline, "1"},
+	{EventStreamOffline, "1"},
+	{EventChannelUpdate, "2"},
+}
+
+// Event describes an EventSub notification
+type Event struct {
+	Type                 string    `json:"-"`
+	BroadcasterUserID    string    `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string    `json:"broadcaster_user_login"`
+	BroadcasterUserName  string    `json:"broadcaster_user_name"`
+	StartedAt            time.Time `json:"started_at,omitempty"`    // stream.online
+	Title                string    `json:"title,omitempty"`         // channel.update
+	Language             string    `json:"language,omitempty"`      // channel.update
+	CategoryID           string    `json:"category_id,omitempty"`   // channel.update
+	CategoryName         string    `json:"category_name,omitempty"` // channel.update
+
+	// ContentClassificationLabels are the label IDs of the channel, on channel.update
+	ContentClassificationLabels []string `json:"content_classification_labels,omitempty"`
+
+	// Subscribed contains the broadcaster IDs watched by the new session on EventConnected.
+	// It may be shorter than requested when the subscriptions limit is reached.
+	Subscribed []string `json:"-"`
+}
+
+type EventSubI interface {
+	// Listen connects to EventSub over WebSocket and subscribes to stream.online, stream.offline and channel.update
+	// for each given broadcaster. Received events are sent to out until ctx is done.
+	// Keepalive, reconnect messages and connection failures are handled, it only returns on fatal errors.
+	// https://dev.twitch.tv/docs/eventsub/handling-websocket-events/
+	Listen(ctx context.Context, broadcasterIDs []string, out chan<- *Event) error
+}
+
+type eventSubClient struct {
+	c   *Client
+	url string
+}
+
+// eventSubMessage describes any message received on the EventSub WebSocket
+type eventSubMessage struct {
+	Metadata struct {
+		MessageID        string `json:"message_id"`
+		MessageType      string `json:"message_type"`
+		SubscriptionType string `json:"subscription_type"`
+	} `json:"metadata"`
+	Payload struct {
+		Session struct {
+			ID                      string `json:"id"`
+			Status                  string `json:"status"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription struct {
+			ID     string `json:"id"`
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"subscription"`
+		Event *Event `json:"event"`
+	} `json:"payload"`
+}
+
+// eventSubConn carries the current connection, replaced on reconnect messages
+type eventSubConn struct {
+	mutex     sync.Mutex
+	conn      *websocket.Conn
+	keepalive time.Duration
+}
+
+func (c *eventSubConn) get() (*websocket.Conn, time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn, c.keepalive
+}
+
+// replace closes the current connection and uses the given one instead
+func (c *eventSubConn) replace(conn *websocket.Conn, keepalive time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+
+	c.conn = conn
+	c.keepalive = keepalive
+}
+
+func (e *eventSubClient) Listen(ctx context.Context, broadcasterIDs []string, out chan<- *Event) error {
+	backoff := eventSubMinBackoff
+	for {
+		established, err := e.session(ctx, broadcasterIDs, out)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Subscriptions are refused, retrying will not help
+		var twitchError *Error
+		if errors.As(err, &twitchError) && twitchError.Status >= http.StatusBadRequest && twitchError.Status < http.StatusInternalServerError {
+			return err
+		}
+
+		if established {
+			backoff = eventSubMinBackoff
+		}
+
+		log.Warningf("eventsub connection lost, reconnecting in %s: %s", backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > eventSubMaxBackoff {
+			backoff = eventSubMaxBackoff
+		}
+	}
+}
+
+// session runs a single EventSub session, from welcome message to connection loss.
+// It returns whether the session has been subscribed.
+func (e *eventSubClient) session(ctx context.Context, broadcasterIDs []string, out chan<- *Event) (bool, error) {
+	conn, welcome, err := dialEventSub(e.url)
+	if err != nil {
+		return false, err
+	}
+
+	current := new(eventSubConn)
+	current.replace(conn, keepaliveTimeout(welcome))
+	defer current.replace(nil, 0)
+
+	// Unblock reads when ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			current.replace(nil, 0)
+		}
+	}()
+
+	log.Debugf("eventsub session %s opened, subscribing to %d channels", welcome.Payload.Session.ID, len(broadcasterIDs))
+	subscribed, err := e.subscribe(ctx, welcome.Payload.Session.ID, broadcasterIDs)
+	if err != nil {
+		return false, err
+	}
+
+	if !send(ctx, out, &Event{Type: EventConnected, Subscribed: subscribed}) {
+		return true, ctx.Err()
+	}
+
+	seen := make(map[string]struct{})
+	for {
+		conn, keepalive := current.get()
+		if conn == nil {
+			return true, ctx.Err()
+		}
+
+		msg, err := readEventSubMessage(conn, keepalive)
+		if err != nil {
+			return true, err
+		}
+
+		if msg.Metadata.MessageType != eventSubReconnect {
+			if !handleEventSubMessage(ctx, msg, seen, out) {
+				return true, ctx.Err()
+			}
+
+			continue
+		}
+
+		// Subscriptions move to the new connection.
+		// Events keep coming on the old one until Twitch closes it, once the new one is welcomed.
+		log.Debugln("eventsub: reconnect requested")
+		newConn, welcome, err := dialEventSub(msg.Payload.Session.ReconnectURL)
+		if err != nil {
+			return true, err
+		}
+
+		for {
+			msg, err := readEventSubMessage(conn, eventSubWelcomeTimeout)
+			if err != nil {
+				break // closed by Twitch
+			}
+
+			if !handleEventSubMessage(ctx, msg, seen, out) {
+				_ = newConn.Close()
+				return true, ctx.Err()
+			}
+		}
+
+		current.replace(newConn, keepaliveTimeout(welcome))
+	}
+}
+
+// handleEventSubMessage sends the event of notification messages to out, dropping duplicates.
+// It returns false if ctx is done.
+func handleEventSubMessage(ctx context.Context, msg *eventSubMessage, seen map[string]struct{}, out chan<- *Event) bool {
+	// Twitch may send the same message more than once
+	if _, ok := seen[msg.Metadata.MessageID]; ok {
+		return true
+	}
+
+	if len(seen) >= eventSubSeenMessages {
+		for k := range seen {
+			delete(seen, k)
+		}
+	}
+	seen[msg.Metadata.MessageID] = struct{}{}
+
+	switch msg.Metadata.MessageType {
+	case eventSubKeepalive:
+		return true
+	case eventSubNotification:
+		event := msg.Payload.Event
+		if event == nil {
+			return true
+		}
+
+		event.Type = msg.Metadata.SubscriptionType
+		log.Tracef("eventsub: received %s for [%s]", event.Type, event.BroadcasterUserLogin)
+		return send(ctx, out, event)
+	case eventSubRevocation:
+		log.Warningf("eventsub: subscription %s revoked: %s", msg.Payload.Subscription.Type, msg.Payload.Subscription.Status)
+		return true
+	default:
+		log.Debugf("eventsub: ignoring %s message", msg.Metadata.MessageType)
+		return true
+	}
+}
+
+// subscribe creates the subscriptions of each broadcaster for the given session.
+// It returns the subscribed broadcasters, which may be fewer than requested when the cost limit is reached.
+func (e *eventSubClient) subscribe(ctx context.Context, sessionID string, broadcasterIDs []string) ([]string, error) {
+	subscribed := make([]string, 0, len(broadcasterIDs))
+	for _, id := range broadcasterIDs {
+		for _, s := range eventSubSubscriptions {
+			err := e.createSubscription(ctx, sessionID, s.Type, s.Version, id)
+
+			var twitchError *Error
+			switch {
+			case err == nil:
+			case errors.As(err, &twitchError) && twitchError.Status == http.StatusConflict:
+				// already exists
+			case errors.As(err, &twitchError) && twitchError.Status == http.StatusTooManyRequests:
+				log.Warningf("eventsub: subscriptions limit reached, watching %d of %d channels", len(subscribed), len(broadcasterIDs))
+				return subscribed, nil
+			default:
+				return subscribed, err
+			}
+		}
+
+		subscribed = append(subscribed, id)
+	}
+
+	return subscribed, nil
+}
+
+// createSubscription subscribes given session to an event type of a broadcaster
+// https://dev.twitch.tv/docs/api/reference/#create-eventsub-subscription
+func (e *eventSubClient) createSubscription(ctx context.Context, sessionID, eventType, version, broadcasterID string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":      eventType,
+		"version":   version,
+		"condition": map[string]string{"broadcaster_user_id": broadcasterID},
+		"transport": map[string]string{"method": "websocket", "session_id": sessionID},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to encode subscription: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.c.baseURL+eventSubscriptionsURI, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return e.c.do(req, nil)
+}
+
+// dialEventSub connects to u and waits for the welcome message
+func dialEventSub(u string) (*websocket.Conn, *eventSubMessage, error) {
+	config, err := websocket.NewConfig(u, "http://localhost/")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid eventsub URL: %w", err)
+	}
+	config.Dialer = &net.Dialer{Timeout: eventSubWelcomeTimeout}
+
+	log.Debugf("eventsub: connecting to %s", u)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to eventsub: %w", err)
+	}
+
+	welcome, err := readEventSubMessage(conn, eventSubWelcomeTimeout)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	if welcome.Metadata.MessageType != eventSubWelcome {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("%w: %s instead of %s", ErrUnexpectedEventSubMessage, welcome.Metadata.MessageType, eventSubWelcome)
+	}
+
+	return conn, welcome, nil
+}
+
+// readEventSubMessage reads the next message, failing if nothing is received within timeout
+func readEventSubMessage(conn *websocket.Conn, timeout time.Duration) (*eventSubMessage, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	msg := new(eventSubMessage)
+	if err := websocket.JSON.Receive(conn, msg); err != nil {
+		return nil, fmt.Errorf("unable to read eventsub message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// keepaliveTimeout returns how long to wait for a message before considering the connection dead
+func keepaliveTimeout(welcome *eventSubMessage) time.Duration {
+	return time.Duration(welcome.Payload.Session.KeepaliveTimeoutSeconds)*time.Second + eventSubKeepaliveGrace
+}
+
+// send sends event to out unless ctx is done first
+func send(ctx context.Context, out chan<- *Event, event *Event) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- event:
+		return true
+	}
+}
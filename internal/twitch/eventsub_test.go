@@ -0,0 +1,114 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+// newTestEventSubClient returns an EventSub client connected to given fake server
+func newTestEventSubClient(t *testing.T, srv *twitchtest.Server) EventSubI {
+	t.Helper()
+	c := newTestClient(t, srv)
+	return &eventSubClient{c: c, url: srv.EventSubURL()}
+}
+
+// receiveEvent waits for the next event
+func receiveEvent(t *testing.T, events <-chan *Event) *Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second * 5):
+		t.Fatalf("no event received")
+		return nil
+	}
+}
+
+// sendEvent sends an event on srv, waiting for a subscribed session to exist
+func sendEvent(t *testing.T, srv *twitchtest.Server, subscriptionType string, event *twitchtest.Event) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for srv.SendEvent(subscriptionType, event) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no session subscribed to %s", subscriptionType)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func Test_eventSubClient_Listen(t *testing.T) {
+	srv := newTestServer(t)
+	e := newTestEventSubClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *Event)
+	errs := make(chan error, 1)
+	go func() { errs <- e.Listen(ctx, []string{"2", "3"}, events) }()
+
+	// Connected and subscribed
+	connected := receiveEvent(t, events)
+	if connected.Type != EventConnected || !reflect.DeepEqual(connected.Subscribed, []string{"2", "3"}) {
+		t.Fatalf("Listen() first event = %+v, want %s for [2 3]", connected, EventConnected)
+	}
+
+	if got := len(srv.Subscriptions()); got != 6 {
+		t.Errorf("Listen() created %d subscriptions, want 6", got)
+	}
+
+	// Notification
+	sendEvent(t, srv, EventStreamOnline, &twitchtest.Event{BroadcasterUserID: "2", BroadcasterUserLogin: "bar", Type: "live"})
+	if got := receiveEvent(t, events); got.Type != EventStreamOnline || got.BroadcasterUserLogin != "bar" {
+		t.Errorf("Listen() event = %+v, want %s for bar", got, EventStreamOnline)
+	}
+
+	// Reconnect keeps subscriptions, no new EventConnected
+	srv.SendReconnect()
+	sendEvent(t, srv, EventChannelUpdate, &twitchtest.Event{BroadcasterUserID: "3", BroadcasterUserLogin: "baz", Title: "foo"})
+	if got := receiveEvent(t, events); got.Type != EventChannelUpdate || got.Title != "foo" {
+		t.Errorf("Listen() event after reconnect = %+v, want %s with title foo", got, EventChannelUpdate)
+	}
+
+	if got := len(srv.Subscriptions()); got != 6 {
+		t.Errorf("Listen() has %d subscriptions after reconnect, want 6", got)
+	}
+
+	// Connection loss creates a new session
+	srv.CloseSessions()
+	if got := receiveEvent(t, events); got.Type != EventConnected {
+		t.Errorf("Listen() event after connection loss = %+v, want %s", got, EventConnected)
+	}
+
+	cancel()
+	select {
+	case err := <-errs:
+		if err != context.Canceled {
+			t.Errorf("Listen() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second * 5):
+		t.Errorf("Listen() did not return after cancel")
+	}
+}
+
+func Test_eventSubClient_Listen_subscriptionLimit(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetSubscriptionLimit(4) // enough for a single broadcaster
+	e := newTestEventSubClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *Event)
+	go func() { _ = e.Listen(ctx, []string{"2", "3"}, events) }()
+
+	connected := receiveEvent(t, events)
+	if !reflect.DeepEqual(connected.Subscribed, []string{"2"}) {
+		t.Errorf("Listen() subscribed = %v, want [2]", connected.Subscribed)
+	}
+}
@@ -0,0 +1,81 @@
+package twitch
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+const (
+	gamesURI = "/games"
+
+	// size of the icon made from box art, requested 3 times larger for a sharper result
+	boxArtWidth  = 24
+	boxArtHeight = 32
+)
+
+var _ GamesI = (*gamesClient)(nil)
+
+// Game describes a Twitch category or game
+type Game struct {
+	BoxArtURL string `json:"box_art_url"` // contains {width} and {height} placeholders
+	ID        string `json:"id"`
+	IgdbID    string `json:"igdb_id"`
+	Name      string `json:"name"`
+}
+
+type GamesI interface {
+	// Get returns the games or categories of given IDs.
+	// IDs are requested by batches of 100, games already read are not requested again.
+	// https://dev.twitch.tv/docs/api/reference/#get-games
+	Get(ctx context.Context, id ...string) ([]*Game, error)
+
+	// BoxArtBytes loads the box art of given game as a menu icon
+	BoxArtBytes(ctx context.Context, game *Game) ([]byte, error)
+}
+
+type gamesClient struct {
+	c *Client
+
+	mutex sync.Mutex
+	games map[string]*Game // by ID, games rarely change
+}
+
+func (g *gamesClient) Get(ctx context.Context, id ...string) ([]*Game, error) {
+	games := make([]*Game, 0, len(id))
+	var missing []string
+	seen := make(map[string]bool, len(id))
+
+	g.mutex.Lock()
+	for _, v := range id {
+		if seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		if game, ok := g.games[v]; ok {
+			games = append(games, game)
+		} else {
+			missing = append(missing, v)
+		}
+	}
+	g.mutex.Unlock()
+
+	read, err := getBatches[*Game](ctx, g.c, g.c.baseURL+gamesURI, "id", missing)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mutex.Lock()
+	for _, game := range read {
+		g.games[game.ID] = game
+	}
+	g.mutex.Unlock()
+
+	return append(games, read...), nil
+}
+
+func (g *gamesClient) BoxArtBytes(ctx context.Context, game *Game) ([]byte, error) {
+	u := strings.NewReplacer("{width}", "72", "{height}", "96").Replace(game.BoxArtURL)
+	return g.c.iconBytes(ctx, u, imageKey("game", u), boxArtWidth, boxArtHeight)
+}
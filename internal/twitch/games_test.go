@@ -0,0 +1,109 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_gamesClient_Get(t *testing.T) {
+	srv := newTestServer(t)
+	var ids []string
+	for i := 0; i < 150; i++ {
+		id := strconv.Itoa(1000 + i)
+		srv.AddGame(&twitchtest.Game{ID: id, Name: "game" + id})
+		ids = append(ids, id)
+	}
+
+	type args struct {
+		id []string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []string // game IDs
+		requests int      // number of requests to /games
+		wantErr  bool
+	}{
+		{
+			name:     "Expected games by batches of 100",
+			args:     args{id: ids},
+			want:     ids,
+			requests: 2,
+		},
+		{
+			name:     "Expected duplicates requested once",
+			args:     args{id: []string{"1000", "1001", "1000"}},
+			want:     []string{"1000", "1001"},
+			requests: 1,
+		},
+		{
+			name:     "Expected unknown game ignored",
+			args:     args{id: []string{"1000", "unknown"}},
+			want:     []string{"1000"},
+			requests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, srv)
+			before := srv.Requests("/games")
+			g := c.Games
+
+			got, err := g.Get(context.Background(), tt.args.id...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			gotIDs := make([]string, 0, len(got))
+			for _, game := range got {
+				gotIDs = append(gotIDs, game.ID)
+			}
+			sort.Strings(gotIDs)
+			if !reflect.DeepEqual(gotIDs, tt.want) {
+				t.Errorf("Get() got = %v, want %v", gotIDs, tt.want)
+			}
+			if requests := srv.Requests("/games") - before; requests != tt.requests {
+				t.Errorf("Get() requests = %v, want %v", requests, tt.requests)
+			}
+
+			// Games already read are not requested again
+			if _, err := g.Get(context.Background(), tt.want...); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if requests := srv.Requests("/games") - before; requests != tt.requests {
+				t.Errorf("Get() again requests = %v, want %v", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func Test_gamesClient_BoxArtBytes(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddGame(&twitchtest.Game{ID: "1000", Name: "foo"})
+	c := newTestClient(t, srv)
+
+	games, err := c.Games.Get(context.Background(), "1000")
+	if err != nil || len(games) != 1 {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := c.Games.BoxArtBytes(context.Background(), games[0])
+		if err != nil {
+			t.Fatalf("BoxArtBytes() error = %v", err)
+		}
+		if len(got) == 0 {
+			t.Errorf("BoxArtBytes() got empty image")
+		}
+	}
+
+	if requests := srv.Requests("/boxart/1000-72x96.jpg"); requests != 1 {
+		t.Errorf("BoxArtBytes() downloads = %v, want 1", requests)
+	}
+}
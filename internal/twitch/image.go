@@ -0,0 +1,351 @@
+package twitch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nfnt/resize"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/image/webp"
+)
+
+const (
+	imageCacheTTL      = time.Hour * 24 * 7 // cached images are downloaded again after this delay
+	cachePruneInterval = time.Hour          // minimum delay between two checks of the cache limits
+
+	defaultCacheMaxSize = 20 << 20            // default maximum size of the cache on disk, in bytes
+	defaultCacheMaxAge  = time.Hour * 24 * 30 // default maximum age of cache entries
+)
+
+// iconScales are the display scales icons are rendered for, so they stay sharp on HiDPI screens
+var iconScales = []float64{1, 1.5, 2}
+
+// imageKey returns the cache key of the image at u. A new URL, such as a changed avatar, makes a new entry.
+func imageKey(prefix, u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return prefix + "-" + hex.EncodeToString(sum[:8])
+}
+
+// errUnsupportedImage is returned when a downloaded image cannot be decoded
+var errUnsupportedImage = errors.New("unsupported image")
+
+// iconBytes downloads the image at u and returns it as a menu icon of given size, rendered for each of iconScales.
+// Icons are cached under name for imageCacheTTL. A zero width or height keeps the image ratio.
+func (c *Client) iconBytes(ctx context.Context, u, name string, width, height uint) ([]byte, error) {
+	// check if exist in cache
+	if data, found := c.retrieveImageFromCache(name); found {
+		log.Debugf("image [%s] found in cache", name)
+		return data, nil
+	}
+
+	img, err := c.downloadImage(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.storeIcon(img, name, width, height)
+}
+
+// downloadImage downloads and decodes the image at u: PNG, JPEG, WebP or the first frame of a GIF.
+// errUnsupportedImage is returned for other formats.
+func (c *Client) downloadImage(ctx context.Context, u string) (image.Image, error) {
+	imageBytes, imageContentType, err := c.download(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// load image
+	var (
+		img         image.Image
+		imageBuffer = bytes.NewBuffer(imageBytes)
+	)
+	switch imageContentType {
+	case "image/png":
+		img, err = png.Decode(imageBuffer)
+	case "image/jpeg":
+		img, err = jpeg.Decode(imageBuffer)
+	case "image/webp":
+		img, err = webp.Decode(imageBuffer)
+	case "image/gif":
+		img, err = gif.Decode(imageBuffer) // first frame of animated ones
+	default:
+		return nil, fmt.Errorf("%w: unexpected image content-type: %s", errUnsupportedImage, imageContentType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to decode image: %v", errUnsupportedImage, err)
+	}
+
+	return img, nil
+}
+
+// download returns the content at u and its detected type
+func (c *Client) download(ctx context.Context, u string) ([]byte, string, error) {
+	httpClient, err := c.currentHTTPClient()
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to make request: %w", err)
+	}
+
+	// download it
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read image URL: %w", err)
+	}
+
+	defer resp.Body.Close() // we are done with body
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("received non 200 response code")
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read image URL: %w", err)
+	}
+
+	// detect image type, using at most the first 512 bytes
+	// https://stackoverflow.com/a/38175140
+	return data, http.DetectContentType(data), nil
+}
+
+// imageFile downloads the image at u as is and returns the path of the cache file storing it under name.
+// The file is downloaded again once older than ttl.
+func (c *Client) imageFile(ctx context.Context, u, name string, ttl time.Duration) (string, error) {
+	path := filepath.Join(c.cache.BasePath, name)
+	if c.cachedSince(name, ttl) {
+		log.Debugf("image [%s] found in cache", name)
+		return path, nil
+	}
+
+	data, contentType, err := c.download(ctx, u)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("%w: unexpected image content-type: %s", errUnsupportedImage, contentType)
+	}
+
+	log.Debugf("storing image [%s] in cache", name)
+	if err := c.storeImageInCache(data, name); err != nil {
+		return "", fmt.Errorf("unable to store image [%s] in cache: %w", name, err)
+	}
+
+	return path, nil
+}
+
+// storeIcon returns img as a menu icon of given size, rendered for each of iconScales, and caches it under name
+func (c *Client) storeIcon(img image.Image, name string, width, height uint) ([]byte, error) {
+	// resize it for each display scale
+	sizes := make([]image.Image, 0, len(iconScales))
+	for _, scale := range iconScales {
+		w, h := uint(math.Round(float64(width)*scale)), uint(math.Round(float64(height)*scale))
+		sizes = append(sizes, resize.Resize(w, h, img, resize.Lanczos3))
+	}
+
+	var (
+		imageBuffer bytes.Buffer
+		err         error
+	)
+	switch runtime.GOOS {
+	case "windows":
+		// Windows need .ico image format, it picks the size matching the screen
+		err = encodeICO(&imageBuffer, sizes)
+	default:
+		// Default re-encode to png, menus scale the largest size down to the screen
+		err = png.Encode(&imageBuffer, sizes[len(sizes)-1])
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode image: %w", err)
+	}
+
+	// store image in cache
+	data := imageBuffer.Bytes()
+	log.Debugf("storing image [%s] in cache", name)
+	if err := c.storeImageInCache(data, name); err != nil {
+		log.Errorf("unable to store image [%s] in cache: %v", name, err)
+	}
+
+	return data, nil
+}
+
+// encodeICO writes given images as a single .ico file, each one stored as PNG
+func encodeICO(w io.Writer, images []image.Image) error {
+	const headerSize, entrySize = 6, 16
+
+	// encode images first, entries need their size
+	data := make([][]byte, len(images))
+	for i, img := range images {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			return err
+		}
+		data[i] = b.Bytes()
+	}
+
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))}) // reserved, icon type, count
+
+	offset := headerSize + entrySize*len(images)
+	for i, img := range images {
+		// a width or height of 256 is written as 0
+		bounds := img.Bounds()
+		_ = binary.Write(&b, binary.LittleEndian, struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}{uint8(bounds.Dx()), uint8(bounds.Dy()), 0, 0, 1, 32, uint32(len(data[i])), uint32(offset)})
+		offset += len(data[i])
+	}
+
+	for _, d := range data {
+		b.Write(d)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// storeImageInCache write given bytes to file, enforcing the cache limits from time to time
+func (c *Client) storeImageInCache(image []byte, name string) error {
+	if err := c.cache.Write(name, image); err != nil {
+		return err
+	}
+
+	c.cacheMutex.Lock()
+	prune := time.Since(c.prunedAt) >= cachePruneInterval
+	c.cacheMutex.Unlock()
+	if prune {
+		c.pruneCache()
+	}
+
+	return nil
+}
+
+// retrieveImageFromCache return given file if exist and was stored less than imageCacheTTL ago
+func (c *Client) retrieveImageFromCache(name string) ([]byte, bool) {
+	if !c.cachedSince(name, imageCacheTTL) {
+		return nil, false
+	}
+
+	data, err := c.cache.Read(name)
+	if err != nil {
+		log.Warningf("error while loading file: %v", err)
+		return nil, false
+	}
+
+	return data, true
+}
+
+// cachedSince returns whether the cache entry name exists and was stored less than ttl ago
+func (c *Client) cachedSince(name string, ttl time.Duration) bool {
+	if !c.cache.Has(name) {
+		return false
+	}
+
+	info, err := os.Stat(filepath.Join(c.cache.BasePath, name))
+	return err == nil && time.Since(info.ModTime()) < ttl
+}
+
+// cacheEntries returns the entries of the cache on disk, most recent first.
+// Caches of additional accounts are subdirectories and are not included.
+func (c *Client) cacheEntries() ([]os.FileInfo, error) {
+	files, err := os.ReadDir(c.cache.BasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]os.FileInfo, 0, len(files))
+	for _, f := range files {
+		if !f.Type().IsRegular() {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			continue // removed meanwhile
+		}
+
+		entries = append(entries, info)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().After(entries[j].ModTime()) })
+	return entries, nil
+}
+
+// pruneCache removes cache entries older than the maximum age, then the oldest ones until the cache fits its maximum size
+func (c *Client) pruneCache() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	c.prunedAt = time.Now()
+
+	entries, err := c.cacheEntries()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("unable to read cache: %v", err)
+		}
+		return
+	}
+
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size()
+		if time.Since(entry.ModTime()) < c.cacheMaxAge && size <= c.cacheMaxSize {
+			continue
+		}
+
+		log.Debugf("removing [%s] from cache", entry.Name())
+		if err := c.cache.Erase(entry.Name()); err != nil {
+			log.Errorf("unable to remove [%s] from cache: %v", entry.Name(), err)
+		}
+	}
+}
+
+// ClearCache removes every image and schedule cached by this client and forgets the users already read
+func (c *Client) ClearCache() error {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	entries, err := c.cacheEntries()
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read cache: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err := c.cache.Erase(entry.Name()); err != nil {
+			return fmt.Errorf("unable to remove [%s] from cache: %w", entry.Name(), err)
+		}
+	}
+
+	if users, ok := c.Users.(*usersClient); ok {
+		users.forget()
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package twitch
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_encodeICO(t *testing.T) {
+	images := []image.Image{
+		image.NewRGBA(image.Rect(0, 0, 32, 32)),
+		image.NewRGBA(image.Rect(0, 0, 64, 64)),
+	}
+
+	var b bytes.Buffer
+	if err := encodeICO(&b, images); err != nil {
+		t.Fatalf("encodeICO() error = %v", err)
+	}
+
+	data := b.Bytes()
+	if got := binary.LittleEndian.Uint16(data[4:]); got != 2 {
+		t.Fatalf("encodeICO() count = %v, want %v", got, 2)
+	}
+
+	for i, want := range []int{32, 64} {
+		entry := data[6+16*i:]
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		img, err := png.Decode(bytes.NewReader(data[offset : offset+size]))
+		if err != nil {
+			t.Fatalf("encodeICO() entry %d error = %v", i, err)
+		}
+		if int(entry[0]) != want || img.Bounds().Dx() != want {
+			t.Errorf("encodeICO() entry %d width = %v, want %v", i, img.Bounds().Dx(), want)
+		}
+	}
+}
+
+func Test_usersClient_ProfileImageBytes(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	user := &User{Login: "foo", ProfileImageURL: srv.URL + "/avatars/foo"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Users.ProfileImageBytes(context.Background(), user); err != nil {
+			t.Fatalf("ProfileImageBytes() error = %v", err)
+		}
+	}
+	if got := srv.Requests("/avatars/foo"); got != 1 {
+		t.Errorf("ProfileImageBytes() requests = %v, want %v", got, 1)
+	}
+
+	// a changed avatar is downloaded again
+	user.ProfileImageURL = srv.URL + "/avatars/bar"
+	if _, err := c.Users.ProfileImageBytes(context.Background(), user); err != nil {
+		t.Fatalf("ProfileImageBytes() error = %v", err)
+	}
+	if got := srv.Requests("/avatars/bar"); got != 1 {
+		t.Errorf("ProfileImageBytes() requests = %v, want %v", got, 1)
+	}
+
+	// expired entries are downloaded again
+	user.ProfileImageURL = srv.URL + "/avatars/foo"
+	old := time.Now().Add(-imageCacheTTL)
+	if err := os.Chtimes(filepath.Join(c.cache.BasePath, imageKey("avatar", user.ProfileImageURL)), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Users.ProfileImageBytes(context.Background(), user); err != nil {
+		t.Fatalf("ProfileImageBytes() error = %v", err)
+	}
+	if got := srv.Requests("/avatars/foo"); got != 2 {
+		t.Errorf("ProfileImageBytes() requests = %v, want %v", got, 2)
+	}
+}
+
+func TestClient_pruneCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		maxAge  time.Duration
+		want    []string
+	}{
+		{
+			name:    "Expected old entries removed",
+			maxSize: defaultCacheMaxSize,
+			maxAge:  time.Hour * 36,
+			want:    []string{"new", "recent"},
+		},
+		{
+			name:    "Expected oldest entries removed above max size",
+			maxSize: 15,
+			maxAge:  defaultCacheMaxAge,
+			want:    []string{"new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, newTestServer(t))
+			c.cacheMaxSize, c.cacheMaxAge = tt.maxSize, tt.maxAge
+
+			now := time.Now()
+			for name, age := range map[string]time.Duration{"new": 0, "recent": time.Hour * 24, "old": time.Hour * 48} {
+				if err := c.cache.Write(name, []byte("0123456789")); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(filepath.Join(c.cache.BasePath, name), now.Add(-age), now.Add(-age)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c.pruneCache()
+
+			got := c.cacheKeys(t)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pruneCache() kept = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ClearCache(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	if err := c.cache.Write("foo", []byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Users.Resolve(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ClearCache(); err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if got := c.cacheKeys(t); len(got) != 0 {
+		t.Errorf("ClearCache() kept = %v, want none", got)
+	}
+
+	before := srv.Requests("/users")
+	if _, err := c.Users.Resolve(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Requests("/users") - before; got != 1 {
+		t.Errorf("Resolve() requests = %v, want %v", got, 1)
+	}
+}
+
+// cacheKeys returns the sorted keys stored in the cache of c
+func (c *Client) cacheKeys(t *testing.T) []string {
+	t.Helper()
+	entries, err := c.cacheEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.Name())
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func Test_usersClient_ProfileImageBytes_formats(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		name        string
+		path        string // profile image path, none when empty
+		placeholder bool
+	}{
+		{name: "Expected PNG image", path: "/avatars/foo.png"},
+		{name: "Expected JPEG image", path: "/avatars/foo.jpg"}, // served as PNG, detected from the content
+		{name: "Expected first frame of GIF image", path: "/avatars/foo.gif"},
+		{name: "Expected WebP image", path: "/avatars/foo.webp"},
+		{name: "Expected placeholder for unsupported format", path: "/avatars/foo.svg", placeholder: true},
+		{name: "Expected placeholder without profile image", placeholder: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, srv)
+			user := &User{ID: "42", Login: "foo_bar"}
+			if tt.path != "" {
+				user.ProfileImageURL = srv.URL + tt.path
+			}
+
+			data, err := c.Users.ProfileImageBytes(context.Background(), user)
+			if err != nil {
+				t.Fatalf("ProfileImageBytes() error = %v", err)
+			}
+
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ProfileImageBytes() decode error = %v", err)
+			}
+
+			// the top of the placeholder disc is only the background
+			want := placeholderColor(user.ID)
+			r, g, b, _ := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/6).RGBA()
+			got := near(uint8(r>>8), want.R) && near(uint8(g>>8), want.G) && near(uint8(b>>8), want.B)
+			if got != tt.placeholder {
+				t.Errorf("ProfileImageBytes() placeholder = %v, want %v", got, tt.placeholder)
+			}
+		})
+	}
+}
+
+// near returns whether both color components are almost the same
+func near(a, b uint8) bool {
+	return a-b < 3 || b-a < 3
+}
+
+func Test_initials(t *testing.T) {
+	tests := []struct {
+		login string
+		want  string
+	}{
+		{login: "foo", want: "F"},
+		{login: "foo_bar", want: "FB"},
+		{login: "_foo__bar_baz", want: "FB"},
+		{login: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			if got := initials(tt.login); got != tt.want {
+				t.Errorf("initials() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"hash/fnv"
+	"image"
+	"image/color"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const placeholderSize = 96 // size of placeholder images, larger than any rendered icon
+
+// placeholderColors are the backgrounds of placeholder images, white text being readable on each of them
+var placeholderColors = []color.RGBA{
+	{R: 0x91, G: 0x46, B: 0xff, A: 0xff}, // Twitch purple
+	{R: 0xe9, G: 0x1e, B: 0x63, A: 0xff},
+	{R: 0x00, G: 0x88, B: 0x7a, A: 0xff},
+	{R: 0x1e, G: 0x6f, B: 0xd9, A: 0xff},
+	{R: 0xe6, G: 0x51, B: 0x00, A: 0xff},
+	{R: 0x6d, G: 0x4c, B: 0x41, A: 0xff},
+	{R: 0x2e, G: 0x7d, B: 0x32, A: 0xff},
+	{R: 0x5e, G: 0x35, B: 0xb1, A: 0xff},
+}
+
+// placeholderFace draws initials, nil if the font cannot be loaded
+var placeholderFace = func() font.Face {
+	f, err := opentype.Parse(gobold.TTF)
+	if err == nil {
+		var face font.Face
+		if face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: placeholderSize * 0.4, DPI: 72, Hinting: font.HintingFull}); err == nil {
+			return face
+		}
+	}
+
+	log.Errorf("unable to load placeholder font: %s", err)
+	return nil
+}()
+
+// initials returns up to two letters standing for given login, e.g. "FB" for foo_bar
+func initials(login string) string {
+	var letters []rune
+	for _, word := range strings.FieldsFunc(login, func(r rune) bool { return r == '_' || r == ' ' }) {
+		letters = append(letters, []rune(word)[0])
+		if len(letters) == 2 {
+			break
+		}
+	}
+
+	return strings.ToUpper(string(letters))
+}
+
+// placeholderColor returns the background color of the placeholder of given user ID, always the same one
+func placeholderColor(id string) color.RGBA {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return placeholderColors[h.Sum32()%uint32(len(placeholderColors))]
+}
+
+// placeholderImage returns the initials of given user on a disc of a color derived from their ID
+func placeholderImage(user *User) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, placeholderSize, placeholderSize))
+	background := image.NewUniform(placeholderColor(user.ID))
+
+	// disc, pixels outside are left transparent
+	const r = placeholderSize / 2
+	for y := 0; y < placeholderSize; y++ {
+		for x := 0; x < placeholderSize; x++ {
+			if dx, dy := x-r, y-r; dx*dx+dy*dy <= r*r {
+				img.Set(x, y, background)
+			}
+		}
+	}
+
+	text := initials(user.Login)
+	if placeholderFace == nil || text == "" {
+		return img
+	}
+
+	// center the text
+	d := &font.Drawer{Dst: img, Src: image.White, Face: placeholderFace}
+	bounds, _ := d.BoundString(text)
+	width := bounds.Max.X - bounds.Min.X
+	height := bounds.Max.Y - bounds.Min.Y
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(placeholderSize)/2 - width/2 - bounds.Min.X,
+		Y: fixed.I(placeholderSize)/2 - height/2 - bounds.Min.Y,
+	}
+	d.DrawString(text)
+
+	return img
+}
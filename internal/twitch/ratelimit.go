@@ -0,0 +1,114 @@
+package twitch
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// rateLimitThreshold is the number of points kept in the bucket, requests wait for the refill below it
+	rateLimitThreshold = 5
+
+	// maxRateLimitWait caps the time spent waiting for a bucket refill
+	maxRateLimitWait = time.Minute
+)
+
+// rateLimiter tracks the Helix rate limit bucket using response headers
+// https://dev.twitch.tv/docs/api/guide/#twitch-rate-limits
+type rateLimiter struct {
+	mutex     sync.Mutex
+	remaining int       // points left in the bucket, negative when unknown
+	reset     time.Time // when the bucket is refilled
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{remaining: -1}
+}
+
+// wait blocks until a request can be sent without emptying the bucket, then reserves a point.
+// The lock is not held while waiting, so every waiting request stops as soon as its ctx is done.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	r.mutex.Lock()
+	d, reset := r.delay(), r.reset
+	if d <= 0 {
+		if r.remaining > 0 {
+			r.remaining--
+		}
+
+		r.mutex.Unlock()
+		return nil
+	}
+	r.mutex.Unlock()
+
+	log.Debugf("rate limit bucket almost empty, waiting %s", d.Round(time.Millisecond))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.reset.Equal(reset) {
+		r.remaining = -1 // refilled, the next response tells us how much
+	}
+
+	return nil
+}
+
+// delay returns how long to wait for the bucket refill, r.mutex must be held
+func (r *rateLimiter) delay() time.Duration {
+	if r.remaining < 0 || r.remaining > rateLimitThreshold {
+		return 0
+	}
+
+	d := time.Until(r.reset)
+	if d <= 0 {
+		r.remaining = -1 // refilled, the next response tells us how much
+		return 0
+	}
+
+	if d > maxRateLimitWait {
+		d = maxRateLimitWait
+	}
+
+	return d
+}
+
+// update reads the bucket state from response headers
+func (r *rateLimiter) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("Ratelimit-Remaining"))
+	if err != nil {
+		return // not a Helix response
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.remaining = remaining
+	r.reset = parseReset(header)
+}
+
+// exhaust marks the bucket as empty, after a 429 response
+func (r *rateLimiter) exhaust(header http.Header) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.remaining = 0
+	r.reset = parseReset(header)
+}
+
+// parseReset returns when the bucket is refilled, from the Ratelimit-Reset header.
+// A second from now is assumed when missing.
+func parseReset(header http.Header) time.Time {
+	reset, err := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Now().Add(time.Second)
+	}
+
+	return time.Unix(reset, 0)
+}
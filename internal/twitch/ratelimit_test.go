@@ -0,0 +1,39 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter_wait(t *testing.T) {
+	r := newRateLimiter()
+	r.remaining, r.reset = rateLimitThreshold, time.Now().Add(time.Minute)
+
+	// a request waits for the refill
+	waiting, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() { _ = r.wait(waiting) }()
+	time.Sleep(time.Millisecond * 20)
+
+	// others must not be blocked by it once canceled
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	started := time.Now()
+	if err := r.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("wait() returned after %s, want as soon as canceled", elapsed)
+	}
+
+	// a refilled bucket does not wait
+	r.mutex.Lock()
+	r.reset = time.Now().Add(-time.Second)
+	r.mutex.Unlock()
+	if err := r.wait(context.Background()); err != nil {
+		t.Errorf("wait() error = %v, want nil once refilled", err)
+	}
+}
@@ -0,0 +1,270 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pageSize = 100 // maximum allowed by Twitch for the "first" parameter
+	maxIDs   = 100 // maximum number of IDs accepted by Twitch in a single request
+
+	maxRetries        = 3                      // maximum number of retries of idempotent requests
+	defaultRetryDelay = time.Millisecond * 500 // first retry delay, doubled on each retry
+)
+
+// page describes a paginated Helix response
+type page[T any] struct {
+	Data       []T `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+// get requests u with given query parameters and decodes the JSON response body into data
+func (c *Client) get(ctx context.Context, u string, q url.Values, data interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("unable to make request: %w", err)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	return c.do(req, data)
+}
+
+// do sends req and decodes the JSON response body into data, if not nil.
+// Requests wait for the rate limit bucket to be refilled when almost empty.
+// Idempotent requests are retried with backoff on network errors, 429 and 5xx responses.
+// A request rejected with 401 is replayed once the session has been renewed.
+// Errors match ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited or ErrServer depending on the status.
+func (c *Client) do(req *http.Request, data interface{}) error {
+	httpClient, err := c.currentHTTPClient()
+	if err != nil {
+		return err
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	replayed := false // once after a 401
+	for attempt := 0; ; attempt++ {
+		retry := idempotent && attempt < maxRetries
+		if err := c.limiter.wait(req.Context()); err != nil {
+			return err
+		}
+
+		accessToken, _ := c.AccessToken() // renewed if rejected
+		status, header, body, err := sendRequest(httpClient, req)
+		switch {
+		case err != nil && retry:
+			log.Debugf("retrying %s after error: %s", req.URL.Path, err)
+			if err := c.backoff(req.Context(), attempt); err != nil {
+				return err
+			}
+
+			continue
+		case err != nil:
+			return fmt.Errorf("twitch error: %w", err)
+		}
+
+		c.limiter.update(header)
+		switch {
+		case status == http.StatusTooManyRequests && retry && header.Get("Ratelimit-Reset") != "":
+			log.Debugf("rate limited on %s, waiting for bucket refill", req.URL.Path)
+			c.limiter.exhaust(header)
+			continue
+		case status == http.StatusTooManyRequests && retry:
+			log.Debugf("rate limited on %s", req.URL.Path)
+			if err := c.backoff(req.Context(), attempt); err != nil {
+				return err
+			}
+
+			continue
+		case status == http.StatusTooManyRequests:
+			return &RateLimitError{Reset: parseReset(header), Err: readStatusError(status, body)}
+		case status >= http.StatusInternalServerError && retry:
+			log.Debugf("retrying %s after status %d", req.URL.Path, status)
+			if err := c.backoff(req.Context(), attempt); err != nil {
+				return err
+			}
+
+			continue
+		case status == http.StatusUnauthorized && !replayed && rewindable(req) && c.refresh(accessToken):
+			log.Debugf("replaying %s with renewed session", req.URL.Path)
+			if httpClient, err = c.currentHTTPClient(); err != nil {
+				return err
+			}
+
+			if err := rewind(req); err != nil {
+				return err
+			}
+
+			replayed = true
+			continue
+		case status == http.StatusUnauthorized:
+			c.reject()
+			return readStatusError(status, body)
+		case status < http.StatusOK || status >= http.StatusMultipleChoices:
+			return readStatusError(status, body)
+		}
+
+		if data == nil || len(body) == 0 {
+			return nil
+		}
+
+		if err := json.Unmarshal(body, data); err != nil {
+			return fmt.Errorf("unable to read response body: %v", err)
+		}
+
+		return nil
+	}
+}
+
+// sendRequest sends req once and reads the whole response
+func sendRequest(httpClient *http.Client, req *http.Request) (int, http.Header, []byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// rewindable returns whether the body of req can be sent again
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind resets the body of req before sending it again
+func rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("unable to replay request: %w", err)
+	}
+
+	req.Body = body
+	return nil
+}
+
+// backoff waits before the next attempt, with exponential delay and jitter
+func (c *Client) backoff(ctx context.Context, attempt int) error {
+	d := c.retryDelay << attempt
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) // #nosec G404 -- jitter does not need a secure random
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// list walks every page of u and merges them into a single list of at most max items.
+// Items are deduplicated using key because pages can overlap between two requests.
+// The whole walk must complete within the client timeout.
+func list[T any](ctx context.Context, c *Client, u string, q url.Values, max int, key func(T) string) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		items  []T
+		seen   = make(map[string]struct{})
+		cursor string
+		first  = pageSize
+	)
+
+	// do not read more than needed
+	if max < first {
+		first = max
+	}
+
+	for {
+		query := make(url.Values, len(q)+2)
+		for k, v := range q {
+			query[k] = v
+		}
+
+		query.Set("first", strconv.Itoa(first))
+		if cursor != "" {
+			query.Set("after", cursor)
+		}
+
+		data := new(page[T])
+		if err := c.get(ctx, u, query, data); err != nil {
+			return nil, err
+		}
+
+		items = appendUnique(items, seen, key, data.Data...)
+		if len(items) >= max {
+			log.Debugf("reached maximum of %d items for %s", max, u)
+			return items[:max], nil
+		}
+
+		// no more pages
+		cursor = data.Pagination.Cursor
+		if cursor == "" || len(data.Data) == 0 {
+			return items, nil
+		}
+	}
+}
+
+// getBatches requests u with given values set to the param query parameter, by batches of maxIDs,
+// and merges the data of every response
+func getBatches[T any](ctx context.Context, c *Client, u, param string, values []string) ([]T, error) {
+	items := make([]T, 0, len(values))
+	for i := 0; i < len(values); i += maxIDs {
+		end := i + maxIDs
+		if end > len(values) {
+			end = len(values)
+		}
+
+		q := make(url.Values)
+		for _, v := range values[i:end] {
+			q.Add(param, v)
+		}
+
+		data := new(page[T])
+		if err := c.get(ctx, u, q, data); err != nil {
+			return nil, err
+		}
+
+		items = append(items, data.Data...)
+	}
+
+	return items, nil
+}
+
+// appendUnique appends each item whose key is not already in seen to items
+func appendUnique[T any](items []T, seen map[string]struct{}, key func(T) string, page ...T) []T {
+	for _, item := range page {
+		k := key(item)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+
+		seen[k] = struct{}{}
+		items = append(items, item)
+	}
+
+	return items
+}
@@ -0,0 +1,320 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+	"golang.org/x/oauth2"
+)
+
+func Test_appendUnique(t *testing.T) {
+	var (
+		a = &Stream{ID: "1"}
+		b = &Stream{ID: "2"}
+		c = &Stream{ID: "3"}
+	)
+
+	type args struct {
+		streams []*Stream
+		seen    map[string]struct{}
+		page    []*Stream
+	}
+	tests := []struct {
+		name string
+		args args
+		want []*Stream
+	}{
+		{
+			name: "Expected without duplicates",
+			args: args{
+				streams: nil,
+				seen:    map[string]struct{}{},
+				page:    []*Stream{a, b},
+			},
+			want: []*Stream{a, b},
+		},
+		{
+			name: "Expected with duplicates across pages",
+			args: args{
+				streams: []*Stream{a, b},
+				seen:    map[string]struct{}{"1": {}, "2": {}},
+				page:    []*Stream{b, c},
+			},
+			want: []*Stream{a, b, c},
+		},
+		{
+			name: "Expected with duplicates in the same page",
+			args: args{
+				streams: nil,
+				seen:    map[string]struct{}{},
+				page:    []*Stream{c, c, a},
+			},
+			want: []*Stream{c, a},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendUnique(tt.args.streams, tt.args.seen, streamID, tt.args.page...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appendUnique() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_do(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		times        int // number of failures, unlimited when zero
+		wantRequests int
+		wantStatus   int // status of the returned Twitch error, if any
+		wantLimited  bool
+	}{
+		{
+			name:         "Expected success after retries",
+			method:       http.MethodGet,
+			status:       http.StatusServiceUnavailable,
+			times:        2,
+			wantRequests: 3,
+		},
+		{
+			name:         "Expected server error after every retry",
+			method:       http.MethodGet,
+			status:       http.StatusInternalServerError,
+			wantRequests: maxRetries + 1,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "Expected rate limit error after every retry",
+			method:       http.MethodGet,
+			status:       http.StatusTooManyRequests,
+			wantRequests: maxRetries + 1,
+			wantStatus:   http.StatusTooManyRequests,
+			wantLimited:  true,
+		},
+		{
+			name:         "Expected no retry of non idempotent requests",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			wantRequests: 1,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "Expected no retry on client errors",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			wantRequests: 1,
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv)
+
+			if tt.times > 0 {
+				srv.FailTimes(usersURI, tt.status, "foo", tt.times)
+			} else {
+				srv.Fail(usersURI, tt.status, "foo")
+			}
+
+			req, _ := http.NewRequest(tt.method, c.baseURL+usersURI, nil)
+			err := c.do(req, new(usersResponse))
+
+			var twitchError *Error
+			if got := errors.As(err, &twitchError); got != (tt.wantStatus != 0) || (got && twitchError.Status != tt.wantStatus) {
+				t.Errorf("do() error = %v, want status %d", err, tt.wantStatus)
+			}
+
+			var rateLimitError *RateLimitError
+			if got := errors.As(err, &rateLimitError); got != tt.wantLimited {
+				t.Errorf("do() error = %v, want rate limit error %t", err, tt.wantLimited)
+			}
+
+			// first request has been made by New
+			if got := srv.Requests(usersURI) - 1; got != tt.wantRequests {
+				t.Errorf("do() made %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClient_do_unauthorized(t *testing.T) {
+	var refreshes int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&refreshes, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","token_type":"bearer","expires_in":3600}`, n, n)
+	}))
+	defer tokenServer.Close()
+
+	tests := []struct {
+		name          string
+		refreshable   bool   // session token can be refreshed
+		accepted      string // only access token accepted by the API, none when empty
+		wantErr       bool
+		wantTokens    []string // access token of each request
+		wantRefreshes int32
+	}{
+		{
+			name:          "Expected replay with the refreshed token",
+			refreshable:   true,
+			accepted:      "access-1",
+			wantTokens:    []string{"access-0", "access-1"},
+			wantRefreshes: 1,
+		},
+		{
+			name:          "Expected error when the refreshed token is rejected too",
+			refreshable:   true,
+			wantErr:       true,
+			wantTokens:    []string{"access-0", "access-1"},
+			wantRefreshes: 1,
+		},
+		{
+			name:       "Expected error without replay when the session cannot be renewed",
+			wantErr:    true,
+			wantTokens: []string{twitchtest.AccessToken},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&refreshes, 0)
+			c := newTestClient(t, newTestServer(t))
+
+			var tokens []string
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				tokens = append(tokens, token)
+				if tt.accepted == "" || token != tt.accepted {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = fmt.Fprint(w, `{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`)
+					return
+				}
+
+				_, _ = fmt.Fprint(w, `{"data":[]}`)
+			}))
+			defer api.Close()
+
+			c.tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: twitchtest.AccessToken})
+			if tt.refreshable {
+				config := &oauth2.Config{
+					ClientID: twitchtest.ClientID,
+					Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams},
+				}
+				token := &oauth2.Token{AccessToken: "access-0", RefreshToken: "refresh-0", Expiry: time.Now().Add(time.Hour)}
+				c.tokenSource = newPersistingTokenSource(context.Background(), config, NewFileTokenStore(""), "", token)
+			}
+			c.httpClient = newHTTPClient(twitchtest.ClientID, c.tokenSource)
+
+			req, _ := http.NewRequest(http.MethodGet, api.URL+usersURI, nil)
+			err := c.do(req, new(usersResponse))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("do() error = %v, want %v", err, ErrUnauthorized)
+			}
+
+			if !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("do() sent tokens %v, want %v", tokens, tt.wantTokens)
+			}
+			if got := atomic.LoadInt32(&refreshes); got != tt.wantRefreshes {
+				t.Errorf("do() refreshed %d times, want %d", got, tt.wantRefreshes)
+			}
+
+			// a session which cannot be refreshed is reported to ValidateEvery
+			select {
+			case <-c.rejected:
+				if !tt.wantErr {
+					t.Errorf("do() reported a refreshed session as rejected")
+				}
+			default:
+				if tt.wantErr {
+					t.Errorf("do() did not report the rejected session")
+				}
+			}
+		})
+	}
+}
+
+func TestClient_do_rateLimit(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	srv.SetRateLimit(rateLimitThreshold+6, time.Second)
+
+	// The bucket is emptied halfway, requests must wait for the refill instead of failing
+	const n = 10
+	for i := 0; i < n; i++ {
+		if _, err := c.Users.Get(context.Background(), "foo"); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+
+	// first request has been made by New
+	if got := srv.Requests(usersURI) - 1; got != n {
+		t.Errorf("Get() made %d requests, want %d without any rate limited one", got, n)
+	}
+}
+
+func TestClient_get_context(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		timeout time.Duration // client timeout
+		wantErr error
+	}{
+		{
+			name:    "Expected canceled",
+			ctx:     func() (context.Context, context.CancelFunc) { return canceled, func() {} },
+			timeout: time.Minute,
+			wantErr: context.Canceled,
+		},
+		{
+			name: "Expected caller deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Millisecond*50)
+			},
+			timeout: time.Minute,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Expected client timeout",
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			timeout: time.Millisecond * 50,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv)
+			c.timeout = tt.timeout
+			srv.SetLatency(time.Second * 10)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := c.Users.Get(ctx, "foo")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second*5 {
+				t.Errorf("Get() returned after %s", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	scheduleURI = "/schedule"
+
+	scheduleCacheTTL = time.Hour * 6  // schedules are read from Twitch a few times a day
+	schedulePast     = time.Hour * 24 // segments started since are listed, they may still be running
+	maxSegments      = 25             // maximum allowed by Twitch for the "first" parameter
+)
+
+var _ ScheduleI = (*scheduleClient)(nil)
+
+type scheduleResponse struct {
+	Data struct {
+		Segments         []*Segment `json:"segments"`
+		BroadcasterID    string     `json:"broadcaster_id"`
+		BroadcasterName  string     `json:"broadcaster_name"`
+		BroadcasterLogin string     `json:"broadcaster_login"`
+	} `json:"data"`
+}
+
+// Segment describes a scheduled broadcast
+type Segment struct {
+	ID            string           `json:"id"`
+	StartTime     time.Time        `json:"start_time"`
+	EndTime       time.Time        `json:"end_time"`
+	Title         string           `json:"title"`
+	CanceledUntil *time.Time       `json:"canceled_until"` // set when this occurrence is canceled
+	Category      *SegmentCategory `json:"category"`       // nil if not set
+	IsRecurring   bool             `json:"is_recurring"`
+
+	// Filled from the schedule, not sent by Twitch for each segment
+	BroadcasterID    string `json:"broadcaster_id"`
+	BroadcasterLogin string `json:"broadcaster_login"`
+	BroadcasterName  string `json:"broadcaster_name"`
+}
+
+// SegmentCategory is the category of a scheduled broadcast
+type SegmentCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Canceled returns whether this occurrence of the segment is canceled
+func (s *Segment) Canceled() bool {
+	return s.CanceledUntil != nil
+}
+
+// cachedSchedule is a schedule stored in cache
+type cachedSchedule struct {
+	ReadAt   time.Time  `json:"read_at"`
+	Segments []*Segment `json:"segments"`
+}
+
+type ScheduleI interface {
+	// Get returns the first 25 segments of given broadcaster schedule, from the ones started in the last 24 hours.
+	// Schedules are cached on disk and read from Twitch again after 6 hours.
+	// Broadcasters without schedule have no segment.
+	// https://dev.twitch.tv/docs/api/reference/#get-channel-stream-schedule
+	Get(ctx context.Context, broadcasterID string) ([]*Segment, error)
+}
+
+type scheduleClient struct {
+	c *Client
+}
+
+func (s *scheduleClient) Get(ctx context.Context, broadcasterID string) ([]*Segment, error) {
+	key := "schedule-" + broadcasterID
+	if cached, ok := s.cached(key); ok {
+		return cached.Segments, nil
+	}
+
+	q := make(url.Values)
+	q.Set("broadcaster_id", broadcasterID)
+	q.Set("start_time", time.Now().Add(-schedulePast).UTC().Format(time.RFC3339))
+	q.Set("first", strconv.Itoa(maxSegments))
+
+	data := new(scheduleResponse)
+	err := s.c.get(ctx, s.c.baseURL+scheduleURI, q, data)
+	if errors.Is(err, ErrNotFound) {
+		err = nil // no schedule
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range data.Data.Segments {
+		segment.BroadcasterID = broadcasterID
+		segment.BroadcasterLogin = data.Data.BroadcasterLogin
+		segment.BroadcasterName = data.Data.BroadcasterName
+	}
+
+	s.store(key, &cachedSchedule{ReadAt: time.Now(), Segments: data.Data.Segments})
+	return data.Data.Segments, nil
+}
+
+// cached returns the schedule stored under key, if read from Twitch less than scheduleCacheTTL ago
+func (s *scheduleClient) cached(key string) (*cachedSchedule, bool) {
+	if !s.c.cache.Has(key) {
+		return nil, false
+	}
+
+	data, err := s.c.cache.Read(key)
+	if err != nil {
+		log.Warningf("unable to read schedule [%s] from cache: %v", key, err)
+		return nil, false
+	}
+
+	cached := new(cachedSchedule)
+	if err := json.Unmarshal(data, cached); err != nil {
+		log.Warningf("unable to read schedule [%s] from cache: %v", key, err)
+		return nil, false
+	}
+
+	return cached, time.Since(cached.ReadAt) < scheduleCacheTTL
+}
+
+// store writes given schedule in cache under key
+func (s *scheduleClient) store(key string, schedule *cachedSchedule) {
+	data, err := json.Marshal(schedule)
+	if err == nil {
+		err = s.c.cache.Write(key, data)
+	}
+
+	if err != nil {
+		log.Errorf("unable to store schedule [%s] in cache: %v", key, err)
+	}
+}
@@ -0,0 +1,100 @@
+package twitch
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_scheduleClient_Get(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddUser(&twitchtest.User{ID: "2", Login: "bar", DisplayName: "Bar"})
+
+	now := time.Now().Truncate(time.Minute)
+	canceled := now.Add(time.Hour * 3)
+	srv.AddSegment("2",
+		&twitchtest.Segment{ID: "old", StartTime: now.Add(-time.Hour * 48), EndTime: now.Add(-time.Hour * 46)},
+		&twitchtest.Segment{ID: "running", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour), Category: &twitchtest.SegmentCategory{ID: "509658", Name: "Just Chatting"}},
+		&twitchtest.Segment{ID: "canceled", StartTime: now.Add(time.Hour * 2), EndTime: canceled, CanceledUntil: &canceled},
+	)
+	srv.AddSegment("3")
+
+	type want struct {
+		id       string
+		login    string
+		category string
+		canceled bool
+	}
+	tests := []struct {
+		name          string
+		broadcasterID string
+		fail          int // status of the first request
+		want          []want
+		wantErr       bool
+	}{
+		{
+			name:          "Expected segments of the last 24 hours and later",
+			broadcasterID: "2",
+			want: []want{
+				{id: "running", login: "bar", category: "Just Chatting"},
+				{id: "canceled", login: "bar", canceled: true},
+			},
+		},
+		{
+			name:          "Expected empty schedule",
+			broadcasterID: "3",
+			want:          []want{},
+		},
+		{
+			name:          "Expected no schedule",
+			broadcasterID: "4",
+			want:          []want{},
+		},
+		{
+			name:          "Expected error",
+			broadcasterID: "2",
+			fail:          http.StatusBadRequest,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, srv)
+			if tt.fail != 0 {
+				srv.FailTimes("/schedule", tt.fail, "bad request", 1)
+			}
+
+			before := srv.Requests("/schedule")
+			for i := 0; i < 2; i++ {
+				segments, err := c.Schedule.Get(context.Background(), tt.broadcasterID)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if tt.wantErr {
+					return
+				}
+
+				got := make([]want, 0, len(segments))
+				for _, s := range segments {
+					w := want{id: s.ID, login: s.BroadcasterLogin, canceled: s.Canceled()}
+					if s.Category != nil {
+						w.category = s.Category.Name
+					}
+					got = append(got, w)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Get() got = %v, want %v", got, tt.want)
+				}
+			}
+
+			// The second call is read from cache
+			if requests := srv.Requests("/schedule") - before; requests != 1 {
+				t.Errorf("Get() requests = %v, want 1", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultScope is always requested on login, to list followed channels
+const defaultScope = "user:read:follows"
+
+// ErrMissingScope is returned when the session was not granted a scope required by a feature.
+// Use errors.As with a *MissingScopeError to know which ones.
+var ErrMissingScope = errors.New("missing twitch permission")
+
+// MissingScopeError lists the scopes required by a feature and not granted to the session.
+// Reauthorize asks the user for them.
+type MissingScopeError struct {
+	Scopes []string
+}
+
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMissingScope, strings.Join(e.Scopes, " "))
+}
+
+// Is makes errors.Is(err, ErrMissingScope) match
+func (e *MissingScopeError) Is(target error) bool {
+	return target == ErrMissingScope
+}
+
+// Scopes returns the scopes granted to the current session, as read on the last validation.
+// It returns nil while they are unknown.
+func (c *Client) Scopes() []string {
+	c.session.RLock()
+	defer c.session.RUnlock()
+
+	if c.scopes == nil {
+		return nil
+	}
+
+	scopes := make([]string, 0, len(c.scopes))
+	for scope := range c.scopes {
+		scopes = append(scopes, scope)
+	}
+
+	sort.Strings(scopes)
+	return scopes
+}
+
+// RequireScopes returns a *MissingScopeError if the current session was not granted all given scopes.
+// Nothing is returned while granted scopes are unknown, Twitch refuses the requests needing them anyway.
+func (c *Client) RequireScopes(scopes ...string) error {
+	c.session.RLock()
+	defer c.session.RUnlock()
+
+	if c.scopes == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, scope := range scopes {
+		if !c.scopes[scope] {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingScopeError{Scopes: missing}
+	}
+
+	return nil
+}
+
+// Reauthorize asks the user to log in again, granting the scopes of the current session along with given ones.
+// The current session is replaced once the user approved, and kept otherwise.
+// Later logins request given scopes too.
+func (c *Client) Reauthorize(ctx context.Context, scopes ...string) error {
+	if c.config.HTTPClient != nil {
+		return errors.New("unable to reauthorize a preconfigured HTTP client")
+	}
+
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+
+	c.session.Lock()
+	c.requested = union(c.requested, scopes)
+	c.session.Unlock()
+
+	return c.login(ctx, false)
+}
+
+// requestedScopes returns the scopes to request on login: the configured ones, the ones added by Reauthorize
+// and the ones granted to the current session
+func (c *Client) requestedScopes() []string {
+	granted := c.Scopes()
+
+	c.session.RLock()
+	defer c.session.RUnlock()
+	return union([]string{defaultScope}, c.config.Scopes, c.requested, granted)
+}
+
+// setScopes remembers the scopes granted to the current session
+func (c *Client) setScopes(scopes []string) {
+	granted := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		granted[scope] = true
+	}
+
+	c.session.Lock()
+	defer c.session.Unlock()
+	c.scopes = granted
+}
+
+// union returns the scopes of every given list, without duplicates, in order of appearance
+func union(lists ...[]string) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, scope := range list {
+			if scope != "" && !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes
+}
@@ -0,0 +1,95 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClient_RequireScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		require []string
+		want    []string // missing scopes
+	}{
+		{
+			name:    "Expected granted scope",
+			granted: []string{"user:read:follows", "chat:read"},
+			require: []string{"chat:read"},
+		},
+		{
+			name:    "Expected missing scope",
+			granted: []string{"user:read:follows"},
+			require: []string{"chat:read", "user:read:follows", "whispers:read"},
+			want:    []string{"chat:read", "whispers:read"},
+		},
+		{
+			name:    "Expected nothing required",
+			granted: []string{"user:read:follows"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			srv.SetScopes(tt.granted...)
+			c := newTestClient(t, srv)
+
+			err := c.RequireScopes(tt.require...)
+			if (err != nil) != (tt.want != nil) {
+				t.Fatalf("RequireScopes() error = %v, want missing %v", err, tt.want)
+			}
+			if err == nil {
+				return
+			}
+
+			if !errors.Is(err, ErrMissingScope) {
+				t.Errorf("RequireScopes() error = %v, want %v", err, ErrMissingScope)
+			}
+
+			var missing *MissingScopeError
+			if !errors.As(err, &missing) || !reflect.DeepEqual(missing.Scopes, tt.want) {
+				t.Errorf("RequireScopes() got = %v, want %v", missing, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Scopes(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	if got, want := c.Scopes(), []string{"user:read:follows"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scopes() got = %v, want %v", got, want)
+	}
+
+	// granted scopes are read again on each validation
+	srv.SetScopes("user:read:follows", "chat:read")
+	if _, err := c.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got, want := c.Scopes(), []string{"chat:read", "user:read:follows"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scopes() got = %v, want %v", got, want)
+	}
+
+	c.forget()
+	if got := c.Scopes(); got != nil {
+		t.Errorf("Scopes() got = %v, want nil once logged out", got)
+	}
+	if err := c.RequireScopes("whispers:read"); err != nil {
+		t.Errorf("RequireScopes() error = %v, want nil while scopes are unknown", err)
+	}
+}
+
+func TestClient_requestedScopes(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetScopes("user:read:follows", "whispers:read")
+	c := newTestClient(t, srv)
+	c.config.Scopes = []string{"chat:read"}
+	c.requested = []string{"chat:read", "moderator:read:followers"}
+
+	want := []string{"user:read:follows", "chat:read", "moderator:read:followers", "whispers:read"}
+	if got := c.requestedScopes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requestedScopes() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	validateURI = "/validate"
+	revokeURI   = "/revoke"
+)
+
+var (
+	// ErrLoggedOut is returned by requests made while no user is logged in
+	ErrLoggedOut = errors.New("not logged in to Twitch")
+
+	// ErrInvalidToken is returned when Twitch does not accept the session anymore.
+	// The user revoked this app, changed their password or the session expired.
+	ErrInvalidToken = errors.New("twitch session is no longer valid")
+
+	// ErrLoginTimeout is returned when the user did not log in in time
+	ErrLoginTimeout = errors.New("twitch login timed out")
+)
+
+// Validation describes a valid session
+type Validation struct {
+	ClientID  string   `json:"client_id"`
+	Login     string   `json:"login"`
+	UserID    string   `json:"user_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"` // seconds before the access token expires
+}
+
+// Login logs the user in and replaces the current session.
+// The stored session is used if any, the user is asked to log in otherwise.
+func (c *Client) Login(ctx context.Context) error {
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+	return c.login(ctx, true)
+}
+
+// refresh silently renews the session after Twitch rejected given access token.
+// It returns whether the session has been renewed.
+func (c *Client) refresh(rejected string) bool {
+	c.session.RLock()
+	tokenSource := c.tokenSource
+	c.session.RUnlock()
+
+	s, ok := tokenSource.(*persistingTokenSource)
+	if !ok {
+		return false
+	}
+
+	s.invalidate(rejected)
+	token, err := s.Token()
+	if err != nil {
+		log.Warningf("unable to refresh rejected twitch session: %s", err)
+		return false
+	}
+
+	return token.AccessToken != rejected
+}
+
+// reject reports a session Twitch keeps rejecting to ValidateEvery
+func (c *Client) reject() {
+	select {
+	case c.rejected <- struct{}{}:
+	default: // already reported
+	}
+}
+
+// login replaces the current session, using the stored one if reuse is set
+func (c *Client) login(ctx context.Context, reuse bool) error {
+	httpClient, tokenSource := c.config.HTTPClient, c.config.TokenSource
+	if httpClient != nil {
+		httpClient = withClientID(httpClient, c.config.ClientID)
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, c.config.loginTimeout())
+		defer cancel()
+
+		var err error
+		tokenSource, err = getToken(ctx, c.config, c.store, c.requestedScopes(), reuse)
+		if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ErrLoginTimeout, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		httpClient = newHTTPClient(c.config.ClientID, tokenSource)
+	}
+
+	c.session.Lock()
+	c.httpClient, c.tokenSource, c.me, c.scopes = httpClient, tokenSource, nil, nil
+	c.session.Unlock()
+
+	// Get current connected user
+	users, err := c.Users.Get(ctx)
+	if err == nil && len(users) == 0 {
+		err = errors.New("current user not found")
+	}
+
+	if err != nil {
+		c.clear() // keep the stored session, Twitch may just be unreachable
+		return fmt.Errorf("unable to initialize client: %w", err)
+	}
+
+	c.session.Lock()
+	c.me = users[0]
+	c.session.Unlock()
+
+	// read granted scopes, which stay unknown if the session cannot be validated
+	if tokenSource != nil {
+		if _, err := c.Validate(ctx); err != nil {
+			log.Warningf("unable to read granted twitch scopes: %s", err)
+		}
+	}
+
+	log.Infof("logged in to Twitch as %s", users[0].Login)
+	return nil
+}
+
+// Logout revokes the current session and removes it from the store.
+// The session is forgotten even if Twitch cannot be reached to revoke it.
+func (c *Client) Logout(ctx context.Context) error {
+	defer c.forget()
+
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"client_id": {c.config.ClientID},
+		"token":     {token.AccessToken},
+	}
+
+	body, status, err := postForm(ctx, c.authClient, c.config.authURL()+revokeURI, form)
+	if err != nil {
+		return fmt.Errorf("unable to revoke token: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("unable to revoke token: %w", readError(body))
+	}
+
+	return nil
+}
+
+// Validate checks the current session against Twitch and remembers its granted scopes.
+// ErrInvalidToken is returned if Twitch does not accept it anymore.
+// https://dev.twitch.tv/docs/authentication/validate-tokens
+func (c *Client) Validate(ctx context.Context) (*Validation, error) {
+	token, err := c.token()
+	var retrieveError *oauth2.RetrieveError
+	if errors.As(err, &retrieveError) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err) // refresh token was rejected
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.authURL()+validateURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make request: %w", err)
+	}
+	req.Header.Set("Authorization", "OAuth "+token.AccessToken)
+
+	resp, err := c.authClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to validate token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, readError(body))
+	default:
+		return nil, fmt.Errorf("unable to validate token: %w", readError(body))
+	}
+
+	validation := new(Validation)
+	if err := json.Unmarshal(body, validation); err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	c.setScopes(validation.Scopes)
+	return validation, nil
+}
+
+// ValidateEvery validates the session now and then at each interval, as required by Twitch, until ctx is done.
+// When the session is no longer valid, it is forgotten, invalid is called and ValidateEvery returns.
+// It is also validated at once when a request is rejected and the session cannot be refreshed.
+// Call Login to authenticate again.
+func (c *Client) ValidateEvery(ctx context.Context, interval time.Duration, invalid func(error)) {
+	for {
+		_, err := c.Validate(ctx)
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			c.forget()
+			invalid(err)
+			return
+		case err != nil && ctx.Err() == nil:
+			log.Warningf("unable to validate twitch session: %s", err) // network may come back
+		case err == nil:
+			log.Debugln("twitch session validated")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugln("received context cancel: ValidateEvery")
+			return // returning not to leak the goroutine
+		case <-time.After(interval):
+		case <-c.rejected:
+			log.Debugln("twitch session rejected, validating it now")
+		}
+	}
+}
+
+// AccessToken returns the access token of the current session, refreshed if needed.
+// It lets other Twitch services, such as chat, use the session.
+func (c *Client) AccessToken() (string, error) {
+	token, err := c.token()
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// token returns the current session token
+func (c *Client) token() (*oauth2.Token, error) {
+	c.session.RLock()
+	tokenSource, loggedIn := c.tokenSource, c.httpClient != nil
+	c.session.RUnlock()
+
+	switch {
+	case !loggedIn:
+		return nil, ErrLoggedOut
+	case tokenSource == nil:
+		return nil, errors.New("missing session token source")
+	}
+
+	return tokenSource.Token()
+}
+
+// currentHTTPClient returns the http.Client of the current session
+func (c *Client) currentHTTPClient() (*http.Client, error) {
+	c.session.RLock()
+	defer c.session.RUnlock()
+
+	if c.httpClient == nil {
+		return nil, ErrLoggedOut
+	}
+
+	return c.httpClient, nil
+}
+
+// clear clears the current session
+func (c *Client) clear() {
+	c.session.Lock()
+	defer c.session.Unlock()
+	c.httpClient, c.tokenSource, c.me, c.scopes = nil, nil, nil, nil
+}
+
+// forget clears the current session and removes it from the store
+func (c *Client) forget() {
+	c.clear()
+	if err := c.store.Delete(); err != nil {
+		log.Errorln(err)
+	}
+}
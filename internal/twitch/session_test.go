@@ -0,0 +1,178 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+	"golang.org/x/oauth2"
+)
+
+func TestClient_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		revoke  bool
+		logout  bool
+		want    string // login of the validated session
+		wantErr error
+	}{
+		{
+			name: "Expected",
+			want: "foo",
+		},
+		{
+			name:    "Expected invalid token once revoked",
+			revoke:  true,
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "Expected error while logged out",
+			logout:  true,
+			wantErr: ErrLoggedOut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv)
+			if tt.revoke {
+				srv.RevokeToken()
+			}
+			if tt.logout {
+				c.forget()
+			}
+
+			got, err := c.Validate(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Login != tt.want {
+				t.Errorf("Validate() got = %v, want %v", got.Login, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_AccessToken(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+
+	got, err := c.AccessToken()
+	if err != nil || got != twitchtest.AccessToken {
+		t.Errorf("AccessToken() got = %v, %v, want %v", got, err, twitchtest.AccessToken)
+	}
+
+	c.forget()
+	if _, err := c.AccessToken(); !errors.Is(err, ErrLoggedOut) {
+		t.Errorf("AccessToken() error = %v, want %v", err, ErrLoggedOut)
+	}
+}
+
+func TestClient_Logout(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	if err := c.store.Save(&oauth2.Token{AccessToken: "foo"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := c.Logout(context.Background()); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	if !srv.Revoked() {
+		t.Errorf("Logout() did not revoke the token")
+	}
+	if me := c.Users.Me(); me != nil {
+		t.Errorf("Logout() Me() = %v, want nil", me)
+	}
+	if _, err := c.Streams.GetFollowed(context.Background()); !errors.Is(err, ErrLoggedOut) {
+		t.Errorf("Logout() GetFollowed() error = %v, want %v", err, ErrLoggedOut)
+	}
+
+	if _, err := c.store.Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Logout() kept the stored token: %v", err)
+	}
+
+	// Log in again
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if me := c.Users.Me(); me == nil || me.Login != "foo" {
+		t.Errorf("Login() Me() = %v, want foo", me)
+	}
+}
+
+func TestClient_ValidateEvery(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	invalid := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.ValidateEvery(ctx, time.Millisecond*10, func(err error) { invalid <- err })
+	}()
+
+	// Valid session is checked again and again
+	deadline := time.Now().Add(time.Second * 5)
+	for srv.Requests("/oauth2/validate") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ValidateEvery() did not validate periodically")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	srv.RevokeToken()
+	select {
+	case err := <-invalid:
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("ValidateEvery() error = %v, want %v", err, ErrInvalidToken)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("ValidateEvery() did not detect the revoked session")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("ValidateEvery() did not return")
+	}
+
+	if me := c.Users.Me(); me != nil {
+		t.Errorf("ValidateEvery() Me() = %v, want nil", me)
+	}
+}
+
+func TestClient_ValidateEvery_rejected(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	invalid := make(chan error, 1)
+	go c.ValidateEvery(ctx, time.Hour, func(err error) { invalid <- err })
+
+	// A rejected request which cannot be refreshed is validated at once
+	srv.RevokeToken()
+	srv.Fail(usersURI, http.StatusUnauthorized, "Invalid OAuth token")
+	if _, err := c.Users.Get(ctx, "bar"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Get() error = %v, want %v", err, ErrUnauthorized)
+	}
+
+	select {
+	case err := <-invalid:
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("ValidateEvery() error = %v, want %v", err, ErrInvalidToken)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("ValidateEvery() did not validate the rejected session")
+	}
+}
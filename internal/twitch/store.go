@@ -0,0 +1,205 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var (
+	_ TokenStore = (*fileTokenStore)(nil)
+
+	// ErrTokenNotFound is returned by a TokenStore without any stored token
+	ErrTokenNotFound = errors.New("no stored token")
+)
+
+// TokenStore saves the session token between runs
+type TokenStore interface {
+	// Load returns the stored token, ErrTokenNotFound if there is none
+	Load() (*oauth2.Token, error)
+
+	// Save replaces the stored token by given one
+	Save(token *oauth2.Token) error
+
+	// Delete removes the stored token, if any
+	Delete() error
+}
+
+// fileTokenStore stores the token as JSON in a file only readable by its owner
+type fileTokenStore struct {
+	account string
+	path    string // defaults to tokenFile in the account directory
+}
+
+// NewFileTokenStore returns a TokenStore writing the token in clear in the file at path.
+// The token is stored in the user config directory when path is empty.
+func NewFileTokenStore(path string) TokenStore {
+	return &fileTokenStore{path: path}
+}
+
+// NewAccountFileTokenStore returns a TokenStore writing the token of given account in clear in the user config directory
+func NewAccountFileTokenStore(account string) TokenStore {
+	return &fileTokenStore{account: account}
+}
+
+func (s *fileTokenStore) Load() (*oauth2.Token, error) {
+	path, err := s.file()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readTokenFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("cannot read token file: %w", err)
+	}
+
+	// Older versions let anyone read it
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		log.Infof("restricting %s permissions", path)
+		if err := os.Chmod(path, 0600); err != nil {
+			log.Warningf("unable to restrict token file permissions: %s", err)
+		}
+	}
+
+	return token, nil
+}
+
+func (s *fileTokenStore) Save(token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("unable to encode token: %w", err)
+	}
+
+	path, err := s.file()
+	if err != nil {
+		return err
+	}
+
+	return writeTokenFile(path, data)
+}
+
+func (s *fileTokenStore) Delete() error {
+	path, err := s.file()
+	if err != nil {
+		return err
+	}
+
+	return deleteTokenFile(path)
+}
+
+// file returns the token file path
+func (s *fileTokenStore) file() (string, error) {
+	if s.path != "" {
+		return s.path, nil
+	}
+
+	return tokenFilePath(s.account, tokenFile)
+}
+
+// tokenFilePath returns the path of the named file in the token directory of given account
+func tokenFilePath(account, name string) (string, error) {
+	dir, err := tokenDirectory(account)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// readTokenFile returns the content of the token file.
+// ErrTokenNotFound is returned if it does not exist.
+func readTokenFile(path string) ([]byte, error) {
+	log.Tracef("reading %s", path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s", ErrTokenNotFound, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot open token file: %w", err)
+	}
+
+	return data, nil
+}
+
+// writeTokenFile replaces the content of the token file, only readable by its owner.
+// The token is written to a temporary file first, then renamed, so readers never see a partial token.
+func writeTokenFile(path string, data []byte) error {
+	log.Tracef("creating temporary token file in %s", filepath.Dir(path))
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp") // created with 0600
+	if err != nil {
+		return fmt.Errorf("unable to open token file: %w", err)
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	log.Debugf("writing token content to %s", path)
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// deleteTokenFile removes the token file, if any
+func deleteTokenFile(path string) error {
+	log.Debugf("removing %s", path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to remove token file: %w", err)
+	}
+
+	return nil
+}
+
+// migrateTokenFile moves the token stored in clear by older versions to the store of the default account
+func migrateTokenFile(store TokenStore) error {
+	if _, ok := store.(*fileTokenStore); ok {
+		return nil // Load restricts its permissions
+	}
+
+	legacy := new(fileTokenStore)
+	token, err := legacy.Load()
+	if errors.Is(err, ErrTokenNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Keep a token already in store, it is more recent
+	if _, err := store.Load(); errors.Is(err, ErrTokenNotFound) {
+		if err := store.Save(token); err != nil {
+			return fmt.Errorf("unable to migrate token: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("unable to migrate token: %w", err)
+	}
+
+	log.Infof("token file %s migrated to the configured token store", tokenFile)
+	return legacy.Delete()
+}
@@ -0,0 +1,175 @@
+package twitch
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+var _ TokenStore = (*encryptedFileTokenStore)(nil)
+
+const (
+	tokenEncryptedFile = "token.enc" // used to store current session token encrypted on disk
+
+	// scrypt parameters recommended for interactive logins
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32 // AES-256
+	saltSize     = 16
+)
+
+var (
+	// ErrMissingPassphrase is returned when an encrypted token file is used without passphrase
+	ErrMissingPassphrase = errors.New("missing token file passphrase")
+
+	// ErrInvalidPassphrase is returned when the token file cannot be decrypted with the given passphrase
+	ErrInvalidPassphrase = errors.New("invalid token file passphrase")
+)
+
+// encryptedToken is the content of an encrypted token file
+type encryptedToken struct {
+	Salt       []byte `json:"salt"`  // scrypt salt, new on each save
+	Nonce      []byte `json:"nonce"` // AES-GCM nonce
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptedFileTokenStore stores the token in a file encrypted with a key derived from a passphrase
+type encryptedFileTokenStore struct {
+	account    string
+	path       string // defaults to tokenEncryptedFile in the account directory
+	passphrase string
+}
+
+// NewEncryptedFileTokenStore returns a TokenStore writing the token in the file at path,
+// encrypted with AES-GCM using a key derived from passphrase with scrypt.
+// The token is stored in the user config directory when path is empty.
+func NewEncryptedFileTokenStore(path, passphrase string) TokenStore {
+	return &encryptedFileTokenStore{path: path, passphrase: passphrase}
+}
+
+// NewAccountEncryptedFileTokenStore returns a TokenStore writing the token of given account in the user config directory,
+// encrypted like NewEncryptedFileTokenStore
+func NewAccountEncryptedFileTokenStore(account, passphrase string) TokenStore {
+	return &encryptedFileTokenStore{account: account, passphrase: passphrase}
+}
+
+func (s *encryptedFileTokenStore) Load() (*oauth2.Token, error) {
+	if s.passphrase == "" {
+		return nil, ErrMissingPassphrase
+	}
+
+	path, err := s.file()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readTokenFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := new(encryptedToken)
+	if err := json.Unmarshal(data, encrypted); err != nil {
+		return nil, fmt.Errorf("cannot read token file: %w", err)
+	}
+
+	aead, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("cannot read token file: invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase // or tampered file, GCM cannot tell
+	}
+
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(plaintext, token); err != nil {
+		return nil, fmt.Errorf("cannot read token file: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *encryptedFileTokenStore) Save(token *oauth2.Token) error {
+	if s.passphrase == "" {
+		return ErrMissingPassphrase
+	}
+
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("unable to encode token: %w", err)
+	}
+
+	encrypted := &encryptedToken{Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(encrypted.Salt); err != nil {
+		return fmt.Errorf("unable to generate salt: %w", err)
+	}
+
+	aead, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return err
+	}
+
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, plaintext, nil)
+	data, err := json.Marshal(encrypted)
+	if err != nil {
+		return fmt.Errorf("unable to encode token: %w", err)
+	}
+
+	path, err := s.file()
+	if err != nil {
+		return err
+	}
+
+	return writeTokenFile(path, data)
+}
+
+func (s *encryptedFileTokenStore) Delete() error {
+	path, err := s.file()
+	if err != nil {
+		return err
+	}
+
+	return deleteTokenFile(path)
+}
+
+// file returns the token file path
+func (s *encryptedFileTokenStore) file() (string, error) {
+	if s.path != "" {
+		return s.path, nil
+	}
+
+	return tokenFilePath(s.account, tokenEncryptedFile)
+}
+
+// cipher returns the AES-GCM cipher keyed with the passphrase and given salt
+func (s *encryptedFileTokenStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
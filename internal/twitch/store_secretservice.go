@@ -0,0 +1,282 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var _ TokenStore = (*secretServiceTokenStore)(nil)
+
+// Secret Service D-Bus API, implemented by GNOME Keyring, KWallet and KeePassXC
+// https://specifications.freedesktop.org/secret-service/latest/
+const (
+	secretServiceName       = "org.freedesktop.secrets"
+	secretServicePath       = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretServiceInterface  = "org.freedesktop.Secret.Service"
+	secretCollectionIface   = "org.freedesktop.Secret.Collection"
+	secretItemInterface     = "org.freedesktop.Secret.Item"
+	secretSessionInterface  = "org.freedesktop.Secret.Session"
+	secretPromptInterface   = "org.freedesktop.Secret.Prompt"
+	secretDefaultCollection = "default"
+	secretNoPrompt          = dbus.ObjectPath("/")
+
+	secretItemLabel      = "Twitch Clip session"
+	secretApplication    = "twitch-clip" // identifies our item among the collection
+	secretDefaultAccount = "(default)"   // account attribute of the default account, not a valid account name
+	secretPromptTimeout  = time.Minute * 2
+	secretContentType    = "application/json"
+	secretPlainAlgorithm = "plain" // the secret goes through the local bus only
+)
+
+var (
+	// ErrKeyringPromptDismissed is returned when the user refuses to unlock the keyring
+	ErrKeyringPromptDismissed = errors.New("keyring prompt dismissed")
+)
+
+// secret is a Secret Service secret, as (oayays) D-Bus structure
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceTokenStore stores the token in the default collection of the Secret Service
+type secretServiceTokenStore struct {
+	conn       *dbus.Conn
+	attributes map[string]string // identify our item
+}
+
+// NewSecretServiceTokenStore returns a TokenStore saving the token in the user keyring
+// through the Secret Service D-Bus API of the session bus
+func NewSecretServiceTokenStore() (TokenStore, error) {
+	return NewAccountSecretServiceTokenStore("")
+}
+
+// NewAccountSecretServiceTokenStore returns a TokenStore saving the token of given account in the user keyring
+// like NewSecretServiceTokenStore
+func NewAccountSecretServiceTokenStore(account string) (TokenStore, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to session bus: %w", err)
+	}
+
+	return newSecretServiceTokenStore(conn, account)
+}
+
+// newSecretServiceTokenStore returns a Secret Service TokenStore using given bus connection
+func newSecretServiceTokenStore(conn *dbus.Conn, account string) (TokenStore, error) {
+	// Searched items only have to include these attributes, the account is always set
+	// so that the default account does not match the items of other accounts
+	if account == "" {
+		account = secretDefaultAccount
+	}
+
+	s := &secretServiceTokenStore{
+		conn:       conn,
+		attributes: map[string]string{"application": secretApplication, "account": account},
+	}
+
+	// Make sure a Secret Service is available
+	session, err := s.openSession()
+	if err != nil {
+		return nil, err
+	}
+
+	s.closeSession(session)
+	return s, nil
+}
+
+func (s *secretServiceTokenStore) Load() (*oauth2.Token, error) {
+	item, err := s.search()
+	if err != nil {
+		return nil, err
+	}
+
+	if item == "" {
+		return nil, fmt.Errorf("%w in keyring", ErrTokenNotFound)
+	}
+
+	session, err := s.openSession()
+	if err != nil {
+		return nil, err
+	}
+	defer s.closeSession(session)
+
+	var sec secret
+	if err := s.conn.Object(secretServiceName, item).Call(secretItemInterface+".GetSecret", 0, session).Store(&sec); err != nil {
+		return nil, fmt.Errorf("unable to read keyring secret: %w", err)
+	}
+
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(sec.Value, token); err != nil {
+		return nil, fmt.Errorf("unable to read keyring secret: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *secretServiceTokenStore) Save(token *oauth2.Token) error {
+	value, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("unable to encode token: %w", err)
+	}
+
+	var collection dbus.ObjectPath
+	if err := s.service().Call(secretServiceInterface+".ReadAlias", 0, secretDefaultCollection).Store(&collection); err != nil {
+		return fmt.Errorf("unable to find default keyring: %w", err)
+	}
+
+	if collection == secretNoPrompt {
+		return errors.New("unable to find default keyring")
+	}
+
+	if err := s.unlock(collection); err != nil {
+		return err
+	}
+
+	session, err := s.openSession()
+	if err != nil {
+		return err
+	}
+	defer s.closeSession(session)
+
+	properties := map[string]dbus.Variant{
+		secretItemInterface + ".Label":      dbus.MakeVariant(secretItemLabel),
+		secretItemInterface + ".Attributes": dbus.MakeVariant(s.attributes),
+	}
+
+	sec := secret{Session: session, Parameters: []byte{}, Value: value, ContentType: secretContentType}
+	var item, prompt dbus.ObjectPath
+	log.Debugln("writing token to keyring")
+	if err := s.conn.Object(secretServiceName, collection).Call(secretCollectionIface+".CreateItem", 0, properties, sec, true).Store(&item, &prompt); err != nil {
+		return fmt.Errorf("unable to write keyring secret: %w", err)
+	}
+
+	return s.prompt(prompt)
+}
+
+func (s *secretServiceTokenStore) Delete() error {
+	item, err := s.search()
+	if err != nil || item == "" {
+		return err
+	}
+
+	var prompt dbus.ObjectPath
+	log.Debugln("removing token from keyring")
+	if err := s.conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt); err != nil {
+		return fmt.Errorf("unable to remove keyring secret: %w", err)
+	}
+
+	return s.prompt(prompt)
+}
+
+func (s *secretServiceTokenStore) service() dbus.BusObject {
+	return s.conn.Object(secretServiceName, secretServicePath)
+}
+
+// search returns our unlocked item, or an empty path if there is none
+func (s *secretServiceTokenStore) search() (dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := s.service().Call(secretServiceInterface+".SearchItems", 0, s.attributes).Store(&unlocked, &locked); err != nil {
+		return "", fmt.Errorf("unable to search keyring: %w", err)
+	}
+
+	switch {
+	case len(unlocked) > 0:
+		return unlocked[0], nil
+	case len(locked) > 0:
+		return locked[0], s.unlock(locked[0])
+	default:
+		return "", nil
+	}
+}
+
+// unlock unlocks given object, prompting the user if needed
+func (s *secretServiceTokenStore) unlock(object dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := s.service().Call(secretServiceInterface+".Unlock", 0, []dbus.ObjectPath{object}).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("unable to unlock keyring: %w", err)
+	}
+
+	return s.prompt(prompt)
+}
+
+// prompt shows given prompt, if any, and waits for the user to complete it
+func (s *secretServiceTokenStore) prompt(prompt dbus.ObjectPath) error {
+	if prompt == secretNoPrompt || prompt == "" {
+		return nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+
+	if err := s.conn.AddMatchSignal(match...); err != nil {
+		return fmt.Errorf("unable to watch keyring prompt: %w", err)
+	}
+	defer func() { _ = s.conn.RemoveMatchSignal(match...) }()
+
+	signals := make(chan *dbus.Signal, 1)
+	s.conn.Signal(signals)
+	defer s.conn.RemoveSignal(signals)
+
+	log.Debugf("waiting for keyring prompt %s", prompt)
+	if err := s.conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("unable to show keyring prompt: %w", err)
+	}
+
+	timeout := time.After(secretPromptTimeout)
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w: timeout", ErrKeyringPromptDismissed)
+		case signal := <-signals:
+			if signal.Path != prompt || signal.Name != secretPromptInterface+".Completed" {
+				continue // another signal of this connection
+			}
+
+			if promptDismissed(signal) {
+				return ErrKeyringPromptDismissed
+			}
+
+			return nil
+		}
+	}
+}
+
+// promptDismissed returns whether a Completed signal reports a dismissed prompt, a missing value being one
+func promptDismissed(signal *dbus.Signal) bool {
+	if len(signal.Body) == 0 {
+		return true
+	}
+
+	dismissed, ok := signal.Body[0].(bool)
+	return !ok || dismissed
+}
+
+// openSession opens a Secret Service session, secrets are transferred without encryption
+func (s *secretServiceTokenStore) openSession() (dbus.ObjectPath, error) {
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := s.service().Call(secretServiceInterface+".OpenSession", 0, secretPlainAlgorithm, dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return "", fmt.Errorf("unable to open keyring session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *secretServiceTokenStore) closeSession(session dbus.ObjectPath) {
+	if err := s.conn.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0).Err; err != nil {
+		log.Warningf("unable to close keyring session: %s", err)
+	}
+}
@@ -0,0 +1,433 @@
+package twitch
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"golang.org/x/oauth2"
+)
+
+// startSessionBus starts a private D-Bus daemon and returns its address.
+// The test is skipped if dbus-daemon is not installed.
+func startSessionBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address", "--address=unix:path="+filepath.Join(t.TempDir(), "bus"))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unable to start dbus-daemon: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("unable to read dbus-daemon address: %s", err)
+	}
+
+	return strings.TrimSpace(address)
+}
+
+// connectSessionBus connects to the bus at address
+func connectSessionBus(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("unable to connect to dbus-daemon: %s", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// fakeSecretService is a Secret Service stand-in keeping secrets in memory
+type fakeSecretService struct {
+	conn *dbus.Conn
+
+	mutex   sync.Mutex
+	items   map[dbus.ObjectPath]*fakeSecretItem
+	locked  map[dbus.ObjectPath]bool
+	dismiss bool // prompts are dismissed
+	next    int  // last object number
+}
+
+type fakeSecretItem struct {
+	service    *fakeSecretService
+	path       dbus.ObjectPath
+	attributes map[string]string
+	value      []byte
+}
+
+type fakeSecretCollection struct{ service *fakeSecretService }
+
+type fakeSecretSession struct{}
+
+type fakeSecretPrompt struct {
+	service *fakeSecretService
+	path    dbus.ObjectPath
+	unlock  []dbus.ObjectPath
+}
+
+const fakeSecretCollectionPath = dbus.ObjectPath("/org/freedesktop/secrets/collection/login")
+
+// newFakeSecretService exports a fake Secret Service on the bus at address
+func newFakeSecretService(t *testing.T, address string) *fakeSecretService {
+	t.Helper()
+	s := &fakeSecretService{
+		conn:   connectSessionBus(t, address),
+		items:  make(map[dbus.ObjectPath]*fakeSecretItem),
+		locked: make(map[dbus.ObjectPath]bool),
+	}
+
+	if err := s.conn.Export(s, secretServicePath, secretServiceInterface); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.conn.Export(&fakeSecretCollection{s}, fakeSecretCollectionPath, secretCollectionIface); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := s.conn.RequestName(secretServiceName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("unable to own %s: %v", secretServiceName, err)
+	}
+
+	return s
+}
+
+// lock locks every item, they must be unlocked through a prompt
+func (s *fakeSecretService) lock(dismiss bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dismiss = dismiss
+	for path := range s.items {
+		s.locked[path] = true
+	}
+}
+
+func (s *fakeSecretService) path(kind string) dbus.ObjectPath {
+	s.next++
+	return dbus.ObjectPath(fmt.Sprintf("%s/%s/%d", secretServicePath, kind, s.next))
+}
+
+func (s *fakeSecretService) OpenSession(algorithm string, _ dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != secretPlainAlgorithm {
+		return dbus.Variant{}, "", dbus.MakeFailedError(errors.New("algorithm not supported"))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := s.path("session")
+	if err := s.conn.Export(fakeSecretSession{}, path, secretSessionInterface); err != nil {
+		return dbus.Variant{}, "", dbus.MakeFailedError(err)
+	}
+
+	return dbus.MakeVariant(""), path, nil
+}
+
+func (s *fakeSecretService) SearchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlocked, locked := make([]dbus.ObjectPath, 0), make([]dbus.ObjectPath, 0)
+	for path, item := range s.items {
+		if !matchAttributes(item.attributes, attributes) {
+			continue
+		}
+
+		if s.locked[path] {
+			locked = append(locked, path)
+		} else {
+			unlocked = append(unlocked, path)
+		}
+	}
+
+	return unlocked, locked, nil
+}
+
+// matchAttributes returns whether attributes include every searched one, as SearchItems does
+func matchAttributes(attributes, search map[string]string) bool {
+	for k, v := range search {
+		if value, ok := attributes[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *fakeSecretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlocked := make([]dbus.ObjectPath, 0)
+	var locked []dbus.ObjectPath
+	for _, path := range objects {
+		if s.locked[path] {
+			locked = append(locked, path)
+		} else {
+			unlocked = append(unlocked, path)
+		}
+	}
+
+	if len(locked) == 0 {
+		return unlocked, secretNoPrompt, nil
+	}
+
+	prompt := &fakeSecretPrompt{service: s, path: s.path("prompt"), unlock: locked}
+	if err := s.conn.Export(prompt, prompt.path, secretPromptInterface); err != nil {
+		return nil, "", dbus.MakeFailedError(err)
+	}
+
+	return unlocked, prompt.path, nil
+}
+
+func (s *fakeSecretService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	if name != secretDefaultCollection {
+		return secretNoPrompt, nil
+	}
+
+	return fakeSecretCollectionPath, nil
+}
+
+func (c *fakeSecretCollection) CreateItem(properties map[string]dbus.Variant, sec secret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := c.service
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var attributes map[string]string
+	if err := properties[secretItemInterface+".Attributes"].Store(&attributes); err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+
+	for _, item := range s.items {
+		if replace && reflect.DeepEqual(item.attributes, attributes) {
+			item.value = sec.Value
+			return item.path, secretNoPrompt, nil
+		}
+	}
+
+	item := &fakeSecretItem{service: s, path: s.path("collection/login"), attributes: attributes, value: sec.Value}
+	if err := s.conn.Export(item, item.path, secretItemInterface); err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+
+	s.items[item.path] = item
+	return item.path, secretNoPrompt, nil
+}
+
+func (i *fakeSecretItem) GetSecret(session dbus.ObjectPath) (secret, *dbus.Error) {
+	i.service.mutex.Lock()
+	defer i.service.mutex.Unlock()
+
+	if i.service.locked[i.path] {
+		return secret{}, dbus.NewError("org.freedesktop.Secret.Error.IsLocked", nil)
+	}
+
+	return secret{Session: session, Parameters: []byte{}, Value: i.value, ContentType: secretContentType}, nil
+}
+
+func (i *fakeSecretItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.service.mutex.Lock()
+	defer i.service.mutex.Unlock()
+
+	delete(i.service.items, i.path)
+	return secretNoPrompt, nil
+}
+
+func (fakeSecretSession) Close() *dbus.Error {
+	return nil
+}
+
+func (p *fakeSecretPrompt) Prompt(_ string) *dbus.Error {
+	s := p.service
+	s.mutex.Lock()
+	dismissed := s.dismiss
+	if !dismissed {
+		for _, path := range p.unlock {
+			delete(s.locked, path)
+		}
+	}
+	s.mutex.Unlock()
+
+	if err := s.conn.Emit(p.path, secretPromptInterface+".Completed", dismissed, dbus.MakeVariant(p.unlock)); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	return nil
+}
+
+func Test_secretServiceTokenStore(t *testing.T) {
+	token := &oauth2.Token{AccessToken: "foo", RefreshToken: "bar", TokenType: "bearer"}
+	tests := []struct {
+		name    string
+		lock    bool // lock the keyring after saving
+		dismiss bool // dismiss the unlock prompt
+		wantErr error
+	}{
+		{
+			name: "Expected",
+		},
+		{
+			name: "Expected unlocked through prompt",
+			lock: true,
+		},
+		{
+			name:    "Expected error on dismissed prompt",
+			lock:    true,
+			dismiss: true,
+			wantErr: ErrKeyringPromptDismissed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startSessionBus(t)
+			service := newFakeSecretService(t, address)
+
+			store, err := newSecretServiceTokenStore(connectSessionBus(t, address), "")
+			if err != nil {
+				t.Fatalf("newSecretServiceTokenStore() error = %v", err)
+			}
+
+			if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+				t.Fatalf("Load() error = %v, want %v", err, ErrTokenNotFound)
+			}
+
+			// Saving twice replaces the item
+			for i := 0; i < 2; i++ {
+				if err := store.Save(token); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			if tt.lock {
+				service.lock(tt.dismiss)
+			}
+
+			got, err := store.Load()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got, token) {
+				t.Errorf("Load() got = %v, want %v", got, token)
+			}
+
+			if err := store.Delete(); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+				t.Errorf("Load() after Delete() error = %v, want %v", err, ErrTokenNotFound)
+			}
+		})
+	}
+}
+
+func Test_secretServiceTokenStore_accounts(t *testing.T) {
+	address := startSessionBus(t)
+	newFakeSecretService(t, address)
+
+	tokens := map[string]*oauth2.Token{
+		"":          {AccessToken: "foo", RefreshToken: "bar", TokenType: "bearer"},
+		"account-1": {AccessToken: "baz", RefreshToken: "qux", TokenType: "bearer"},
+	}
+
+	stores := make(map[string]TokenStore, len(tokens))
+	for account, token := range tokens {
+		store, err := newSecretServiceTokenStore(connectSessionBus(t, address), account)
+		if err != nil {
+			t.Fatalf("newSecretServiceTokenStore() error = %v", err)
+		}
+
+		if err := store.Save(token); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		stores[account] = store
+	}
+
+	// Each account reads its own session, whichever was saved first
+	for account, store := range stores {
+		got, err := store.Load()
+		if err != nil {
+			t.Fatalf("Load() of %q error = %v", account, err)
+		}
+		if !reflect.DeepEqual(got, tokens[account]) {
+			t.Errorf("Load() of %q got = %v, want %v", account, got, tokens[account])
+		}
+	}
+
+	// Deleting the session of the default account keeps the other one
+	if err := stores[""].Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := stores[""].Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() after Delete() error = %v, want %v", err, ErrTokenNotFound)
+	}
+	if got, err := stores["account-1"].Load(); err != nil || !reflect.DeepEqual(got, tokens["account-1"]) {
+		t.Errorf("Load() of other account got = %v, %v, want %v", got, err, tokens["account-1"])
+	}
+}
+
+func Test_newSecretServiceTokenStore_unavailable(t *testing.T) {
+	address := startSessionBus(t)
+	if _, err := newSecretServiceTokenStore(connectSessionBus(t, address), ""); err == nil {
+		t.Errorf("newSecretServiceTokenStore() without Secret Service error = nil, want error")
+	}
+}
+
+func Test_promptDismissed(t *testing.T) {
+	tests := []struct {
+		name string
+		body []interface{}
+		want bool
+	}{
+		{
+			name: "Expected approved",
+			body: []interface{}{false, dbus.MakeVariant("")},
+			want: false,
+		},
+		{
+			name: "Expected dismissed",
+			body: []interface{}{true, dbus.MakeVariant("")},
+			want: true,
+		},
+		{
+			name: "Expected dismissed without value",
+			body: nil,
+			want: true,
+		},
+		{
+			name: "Expected dismissed with an unexpected value",
+			body: []interface{}{"foo"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promptDismissed(&dbus.Signal{Body: tt.body}); got != tt.want {
+				t.Errorf("promptDismissed() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package twitch
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func Test_fileTokenStore(t *testing.T) {
+	useTemporaryConfigDir(t)
+	store := NewFileTokenStore("")
+	token := &oauth2.Token{AccessToken: "foo", RefreshToken: "bar", TokenType: "bearer"}
+
+	if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("Load() error = %v, want %v", err, ErrTokenNotFound)
+	}
+
+	if err := store.Save(token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	path, _ := tokenFilePath("", tokenFile)
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Save() file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	// files written by older versions are readable by anyone
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, token) {
+		t.Errorf("Load() got = %v, want %v", got, token)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Load() file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	if err := store.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() after Delete() error = %v, want %v", err, ErrTokenNotFound)
+	}
+}
+
+func Test_fileTokenStore_accounts(t *testing.T) {
+	useTemporaryConfigDir(t)
+	stores := map[string]TokenStore{
+		"":    NewFileTokenStore(""),
+		"foo": NewAccountFileTokenStore("foo"),
+		"bar": NewAccountFileTokenStore("bar"),
+	}
+
+	for account, store := range stores {
+		if err := store.Save(&oauth2.Token{AccessToken: account}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	for account, store := range stores {
+		got, err := store.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got.AccessToken != account {
+			t.Errorf("Load() got = %v, want %v", got.AccessToken, account)
+		}
+	}
+
+	if err := stores["foo"].Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := stores["bar"].Load(); err != nil {
+		t.Errorf("Load() of another account after Delete() error = %v", err)
+	}
+}
+
+func Test_encryptedFileTokenStore(t *testing.T) {
+	token := &oauth2.Token{AccessToken: "foo", RefreshToken: "bar", TokenType: "bearer"}
+	tests := []struct {
+		name       string
+		passphrase string // used to load the token saved with "secret"
+		wantErr    error
+	}{
+		{
+			name:       "Expected",
+			passphrase: "secret",
+		},
+		{
+			name:       "Expected error with wrong passphrase",
+			passphrase: "wrong",
+			wantErr:    ErrInvalidPassphrase,
+		},
+		{
+			name:       "Expected error without passphrase",
+			passphrase: "",
+			wantErr:    ErrMissingPassphrase,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tokenEncryptedFile)
+			if err := (&encryptedFileTokenStore{path: path, passphrase: "secret"}).Save(token); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			if bytes.Contains(data, []byte(token.RefreshToken)) {
+				t.Errorf("Save() wrote the token in clear: %s", data)
+			}
+
+			got, err := (&encryptedFileTokenStore{path: path, passphrase: tt.passphrase}).Load()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, token) {
+				t.Errorf("Load() got = %v, want %v", got, token)
+			}
+		})
+	}
+}
+
+func Test_migrateTokenFile(t *testing.T) {
+	legacyToken := &oauth2.Token{AccessToken: "legacy"}
+	tests := []struct {
+		name   string
+		stored *oauth2.Token // already in the new store
+		want   string
+	}{
+		{
+			name: "Expected legacy token migrated",
+			want: "legacy",
+		},
+		{
+			name:   "Expected stored token kept",
+			stored: &oauth2.Token{AccessToken: "stored"},
+			want:   "stored",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTemporaryConfigDir(t)
+			legacy := NewFileTokenStore("")
+			if err := legacy.Save(legacyToken); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			store := NewEncryptedFileTokenStore("", "secret")
+			if tt.stored != nil {
+				if err := store.Save(tt.stored); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			if err := migrateTokenFile(store); err != nil {
+				t.Fatalf("migrateTokenFile() error = %v", err)
+			}
+
+			got, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got.AccessToken != tt.want {
+				t.Errorf("migrateTokenFile() got = %v, want %v", got.AccessToken, tt.want)
+			}
+			if _, err := legacy.Load(); !errors.Is(err, ErrTokenNotFound) {
+				t.Errorf("migrateTokenFile() kept the legacy file: %v", err)
+			}
+		})
+	}
+}
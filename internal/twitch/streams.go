@@ -1,17 +1,23 @@
 package twitch
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	streamsURI         = "/streams"
 	followedStreamsURI = "/streams/followed"
+
+	// size of stream previews, as displayed by notifications
+	thumbnailWidth  = 440
+	thumbnailHeight = 248
+	thumbnailTTL    = time.Minute * 5 // Twitch refreshes previews every few minutes
 )
 
 var _ StreamsI = (*streamsClient)(nil)
@@ -20,13 +26,6 @@ var (
 	ErrTooManyUserLoginNames = errors.New("too many user login sets. Cannot be more than 100")
 )
 
-type streamsResponse struct {
-	Data       []*Stream `json:"data"`
-	Pagination struct {
-		Cursor string `json:"cursor"`
-	} `json:"pagination"`
-}
-
 // Stream describes a Twitch stream
 type Stream struct {
 	GameID       string    `json:"game_id,omitempty"`
@@ -35,7 +34,7 @@ type Stream struct {
 	IsMature     bool      `json:"is_mature,omitempty"`
 	Language     string    `json:"language,omitempty"`
 	StartedAt    time.Time `json:"started_at,omitempty"`
-	TagIds       []string  `json:"tag_ids,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
 	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
 	Title        string    `json:"title,omitempty"`
 	Type         string    `json:"type,omitempty"`
@@ -43,110 +42,73 @@ type Stream struct {
 	UserLogin    string    `json:"user_login,omitempty"`
 	UserName     string    `json:"user_name,omitempty"`
 	ViewerCount  int       `json:"viewer_count,omitempty"`
+
+	// ContentClassificationLabels are not listed with streams, they are read from the channel. See ChannelsI.Get.
+	ContentClassificationLabels []string `json:"content_classification_labels,omitempty"`
+
+	// Unclassified is set when ContentClassificationLabels could not be read, the stream may have any label
+	Unclassified bool `json:"-"`
 }
 
 type StreamsI interface {
 	// GetStream returns information about active streams.
 	// Streams are returned sorted by number of current viewers, in descending order.
 	// If any, returns streams broadcast by one or more specified user login names. You can specify up to 100 names.
+	// Every page is read, up to the configured maximum number of streams.
 	// https://dev.twitch.tv/docs/api/reference#get-streams
-	GetStream(userLogin ...string) ([]*Stream, error)
+	GetStream(ctx context.Context, userLogin ...string) ([]*Stream, error)
 
 	// GetFollowed returns information about active streams belonging to channels that the authenticated user follows.
 	// Streams are returned sorted by number of current viewers, in descending order.
 	// Across multiple pages of results, there may be duplicate or missing streams, as viewers join and leave streams.
+	// Every page is read, up to the configured maximum number of streams, and duplicates are removed.
 	// https://dev.twitch.tv/docs/api/reference#get-followed-streams
-	GetFollowed() ([]*Stream, error)
+	GetFollowed(ctx context.Context) ([]*Stream, error)
+
+	// ThumbnailPath downloads the preview of given stream and returns the path of its file.
+	// A single preview is cached per streamer, and downloaded again after a few minutes.
+	ThumbnailPath(ctx context.Context, stream *Stream) (string, error)
 }
 
 type streamsClient struct {
 	c *Client
 }
 
-func (s *streamsClient) GetStream(userLogin ...string) ([]*Stream, error) {
+func (s *streamsClient) GetStream(ctx context.Context, userLogin ...string) ([]*Stream, error) {
 	if len(userLogin) > 100 {
 		return nil, ErrTooManyUserLoginNames
 	}
 
-	u := apiURL + streamsURI
-	req, err := http.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to make requestw: %w", err)
-	}
-
 	// Specify wanted users
-	q := req.URL.Query()
+	q := make(url.Values)
 	for _, u := range userLogin {
 		q.Add("user_login", u)
 	}
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := s.c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("twitch error: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// try to read Twitch error
-		e := new(Error)
-		if err := json.Unmarshal(body, e); err == nil {
-			return nil, e
-		}
-
-		return nil, fmt.Errorf("twitch error: %s", body)
-	}
-
-	data := new(streamsResponse)
-	if err := json.Unmarshal(body, data); err != nil {
-		return nil, fmt.Errorf("unable to read response body: %v", err)
-	}
 
-	return data.Data, nil
+	return list(ctx, s.c, s.c.baseURL+streamsURI, q, s.c.maxStreams, streamID)
 }
 
-func (s *streamsClient) GetFollowed() ([]*Stream, error) {
-	u := apiURL + followedStreamsURI
-	req, err := http.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to make requestw: %w", err)
+func (s *streamsClient) GetFollowed(ctx context.Context) ([]*Stream, error) {
+	me := s.c.Users.Me()
+	if me == nil {
+		return nil, ErrLoggedOut
 	}
 
-	// Specify wanted users
-	q := req.URL.Query()
-	q.Set("user_id", s.c.Users.Me().ID)
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := s.c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("twitch error: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// try to read Twitch error
-		e := new(Error)
-		if err := json.Unmarshal(body, e); err == nil {
-			return nil, e
-		}
+	q := make(url.Values)
+	q.Set("user_id", me.ID)
+	return list(ctx, s.c, s.c.baseURL+followedStreamsURI, q, s.c.maxStreams, streamID)
+}
 
-		return nil, fmt.Errorf("twitch error: %s", body)
-	}
+// streamID identifies streams across pages
+func streamID(s *Stream) string {
+	return s.ID
+}
 
-	data := new(streamsResponse)
-	if err := json.Unmarshal(body, data); err != nil {
-		return nil, fmt.Errorf("unable to read response body: %v", err)
+func (s *streamsClient) ThumbnailPath(ctx context.Context, stream *Stream) (string, error) {
+	if stream.ThumbnailURL == "" {
+		return "", fmt.Errorf("no preview for stream of %s", stream.UserLogin)
 	}
 
-	return data.Data, nil
+	u := strings.NewReplacer("{width}", strconv.Itoa(thumbnailWidth), "{height}", strconv.Itoa(thumbnailHeight)).Replace(stream.ThumbnailURL)
+	return s.c.imageFile(ctx, u, "thumbnail-"+stream.UserID, thumbnailTTL)
 }
@@ -1,11 +1,49 @@
 package twitch
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
 )
 
+// streamIDs returns the ID of each given stream
+func streamIDs(streams []*Stream) []string {
+	ids := make([]string, 0, len(streams))
+	for _, s := range streams {
+		ids = append(ids, s.ID)
+	}
+
+	return ids
+}
+
+// addTestStreams adds n live streams to srv, followed by the authenticated user
+func addTestStreams(srv *twitchtest.Server, n int) []string {
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(100 + i)
+		srv.AddUser(&twitchtest.User{ID: id, Login: "user" + id})
+		srv.AddStream(&twitchtest.Stream{ID: "s" + id, UserID: id, UserLogin: "user" + id, Type: "live"})
+		srv.Follow(id)
+		ids = append(ids, "s"+id)
+	}
+
+	return ids
+}
+
 func Test_streamsClient_GetStreams(t *testing.T) {
+	srv := newTestServer(t)
+	ids := addTestStreams(srv, 5)
+	c := newTestClient(t, srv)
+
+	tooMany := make([]string, 101)
+
 	type fields struct {
 		c *Client
 	}
@@ -16,24 +54,157 @@ func Test_streamsClient_GetStreams(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
-		want    []*Stream
+		want    []string // stream IDs
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:   "Expected every stream",
+			fields: fields{c: c},
+			args:   args{userLogin: nil},
+			want:   ids,
+		},
+		{
+			name:   "Expected filtered by user login",
+			fields: fields{c: c},
+			args:   args{userLogin: []string{"user101", "user103"}},
+			want:   []string{"s101", "s103"},
+		},
+		{
+			name:   "Expected no stream",
+			fields: fields{c: c},
+			args:   args{userLogin: []string{"unknown"}},
+			want:   []string{},
+		},
+		{
+			name:    "Expected error with too many user logins",
+			fields:  fields{c: c},
+			args:    args{userLogin: tooMany},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &streamsClient{
 				c: tt.fields.c,
 			}
-			got, err := s.GetStream(tt.args.userLogin...)
+			got, err := s.GetStream(context.Background(), tt.args.userLogin...)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetStream() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetStream() got = %v, want %v", got, tt.want)
+			if err == nil && !reflect.DeepEqual(streamIDs(got), tt.want) {
+				t.Errorf("GetStream() got = %v, want %v", streamIDs(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_streamsClient_GetFollowed(t *testing.T) {
+	tests := []struct {
+		name       string
+		streams    int
+		pageSize   int
+		overlap    bool
+		maxStreams int
+		fail       int // status code returned by the server
+		want       int // number of streams
+		wantPages  int
+		wantErr    bool
+	}{
+		{
+			name:      "Expected single page",
+			streams:   3,
+			pageSize:  100,
+			want:      3,
+			wantPages: 1,
+		},
+		{
+			name:      "Expected every page",
+			streams:   25,
+			pageSize:  10,
+			want:      25,
+			wantPages: 3,
+		},
+		{
+			name:      "Expected without duplicates across overlapping pages",
+			streams:   25,
+			pageSize:  10,
+			overlap:   true,
+			want:      25,
+			wantPages: 3,
+		},
+		{
+			name:       "Expected capped",
+			streams:    25,
+			pageSize:   10,
+			maxStreams: 15,
+			want:       15,
+			wantPages:  2,
+		},
+		{
+			name:      "Expected error",
+			streams:   3,
+			pageSize:  100,
+			fail:      http.StatusBadRequest,
+			wantPages: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			ids := addTestStreams(srv, tt.streams)
+			srv.SetPageSize(tt.pageSize)
+			srv.SetOverlap(tt.overlap)
+			srv.Fail(followedStreamsURI, tt.fail, "foo")
+
+			c := newTestClient(t, srv)
+			if tt.maxStreams > 0 {
+				c.maxStreams = tt.maxStreams
+			}
+
+			got, err := c.Streams.GetFollowed(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFollowed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(streamIDs(got), ids[:tt.want]) {
+				t.Errorf("GetFollowed() got = %v, want %v", streamIDs(got), ids[:tt.want])
+			}
+			if pages := srv.Requests(followedStreamsURI); pages != tt.wantPages {
+				t.Errorf("GetFollowed() requested %d pages, want %d", pages, tt.wantPages)
 			}
 		})
 	}
 }
+
+func Test_streamsClient_ThumbnailPath(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	stream := &Stream{UserID: "2", UserLogin: "bar", ThumbnailURL: srv.URL + "/previews/live_user_bar-{width}x{height}.jpg"}
+	const path = "/previews/live_user_bar-440x248.jpg"
+
+	for i := 0; i < 2; i++ {
+		got, err := c.Streams.ThumbnailPath(context.Background(), stream)
+		if err != nil {
+			t.Fatalf("ThumbnailPath() error = %v", err)
+		}
+		if _, err := os.Stat(got); err != nil {
+			t.Fatalf("ThumbnailPath() file error = %v", err)
+		}
+	}
+	if got := srv.Requests(path); got != 1 {
+		t.Errorf("ThumbnailPath() requests = %v, want %v", got, 1)
+	}
+
+	// previews are downloaded again after a few minutes
+	old := time.Now().Add(-thumbnailTTL)
+	if err := os.Chtimes(filepath.Join(c.cache.BasePath, "thumbnail-2"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Streams.ThumbnailPath(context.Background(), stream); err != nil {
+		t.Fatalf("ThumbnailPath() error = %v", err)
+	}
+	if got := srv.Requests(path); got != 2 {
+		t.Errorf("ThumbnailPath() requests = %v, want %v", got, 2)
+	}
+}
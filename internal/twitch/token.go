@@ -0,0 +1,146 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var _ oauth2.TokenSource = (*persistingTokenSource)(nil)
+
+const (
+	tokenLockFile    = "token.dat.lock" // prevents several processes to refresh the token at the same time
+	tokenLockTimeout = time.Second * 10 // maximum time to wait for the lock
+	tokenLockStale   = time.Second * 30 // a lock older than this is considered abandoned
+)
+
+// ErrTokenLocked is returned when the token file lock cannot be acquired in time
+var ErrTokenLocked = errors.New("token file is locked by another process")
+
+// persistingTokenSource refreshes the token when needed and saves each new token back to its store.
+// Refresh tokens are rotated by Twitch, so a token not saved would log us out on next start.
+type persistingTokenSource struct {
+	ctx     context.Context // carry the oauth2.HTTPClient used to refresh
+	config  *oauth2.Config
+	store   TokenStore
+	account string // owner of the token
+
+	mutex sync.Mutex
+	token *oauth2.Token // last known token
+}
+
+// newPersistingTokenSource returns an oauth2.TokenSource starting with given token of account
+// and saving each refreshed token in store
+func newPersistingTokenSource(ctx context.Context, config *oauth2.Config, store TokenStore, account string, token *oauth2.Token) oauth2.TokenSource {
+	return &persistingTokenSource{
+		ctx:     ctx,
+		config:  config,
+		store:   store,
+		account: account,
+		token:   token,
+	}
+}
+
+// invalidate makes the next Token call refresh the token if its access token is still the given one,
+// rejected by Twitch before it expires
+func (s *persistingTokenSource) invalidate(accessToken string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token.AccessToken != accessToken {
+		return // already refreshed
+	}
+
+	token := *s.token
+	token.Expiry = time.Now().Add(-time.Second)
+	s.token = &token
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	// Another process may be refreshing the same token right now
+	unlock, err := lockTokenFile(s.account)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Another process may already have refreshed and rotated it, prefer the stored one
+	if stored, err := s.store.Load(); err == nil && stored.RefreshToken != "" {
+		if stored.Valid() && stored.AccessToken != s.token.AccessToken {
+			log.Debugln("using token refreshed by another process")
+			s.token = stored
+			return stored, nil
+		}
+
+		s.token = stored
+	}
+
+	// the stored token may be the invalidated one, only keep its refresh token
+	log.Debugln("refreshing token")
+	token, err := s.config.TokenSource(s.ctx, &oauth2.Token{RefreshToken: s.token.RefreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh token: %w", err)
+	}
+
+	// A failure here must not fail the current request, we still have a valid token
+	if err := s.store.Save(token); err != nil {
+		log.Errorf("unable to store refreshed token: %s", err)
+	}
+
+	s.token = token
+	return token, nil
+}
+
+// lockTokenFile creates a lock file next to the token file of account and returns a function releasing it.
+// It waits up to tokenLockTimeout for another process to release it and ignores stale locks.
+func lockTokenFile(account string) (func(), error) {
+	dir, err := tokenDirectory(account)
+	if err != nil {
+		return nil, err
+	}
+
+	lockFilePath := filepath.Join(dir, tokenLockFile)
+	deadline := time.Now().Add(tokenLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_ = f.Close()
+			return func() {
+				if err := os.Remove(lockFilePath); err != nil {
+					log.Warningf("unable to remove token lock: %s", err)
+				}
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to create token lock: %w", err)
+		}
+
+		// Owner probably died while holding it
+		if info, err := os.Stat(lockFilePath); err == nil && time.Since(info.ModTime()) > tokenLockStale {
+			log.Warningf("removing stale token lock %s", lockFilePath)
+			_ = os.Remove(lockFilePath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrTokenLocked
+		}
+
+		time.Sleep(time.Millisecond * 100)
+	}
+}
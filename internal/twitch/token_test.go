@@ -0,0 +1,117 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// useTemporaryConfigDir makes os.UserConfigDir return a temporary directory for the current test
+func useTemporaryConfigDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+func Test_persistingTokenSource_Token(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","token_type":"bearer","expires_in":3600}`, n, n)
+	}))
+	defer srv.Close()
+
+	config := &oauth2.Config{
+		ClientID: "foo",
+		Endpoint: oauth2.Endpoint{TokenURL: srv.URL, AuthStyle: oauth2.AuthStyleInParams},
+	}
+	expired := &oauth2.Token{AccessToken: "access-0", RefreshToken: "refresh-0", Expiry: time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name      string
+		onDisk    *oauth2.Token
+		want      string
+		wantCalls int32
+	}{
+		{
+			name:      "Expected refresh and store",
+			onDisk:    expired,
+			want:      "access-1",
+			wantCalls: 1,
+		},
+		{
+			name:      "Expected token refreshed by another process",
+			onDisk:    &oauth2.Token{AccessToken: "other", RefreshToken: "refresh-other", Expiry: time.Now().Add(time.Hour)},
+			want:      "other",
+			wantCalls: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTemporaryConfigDir(t)
+			atomic.StoreInt32(&calls, 0)
+			store := NewFileTokenStore("")
+			if err := store.Save(tt.onDisk); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			s := newPersistingTokenSource(context.Background(), config, store, "", expired)
+			got, err := s.Token()
+			if err != nil {
+				t.Fatalf("Token() error = %v", err)
+			}
+			if got.AccessToken != tt.want {
+				t.Errorf("Token() got = %v, want %v", got.AccessToken, tt.want)
+			}
+			if c := atomic.LoadInt32(&calls); c != tt.wantCalls {
+				t.Errorf("Token() refreshed %d times, want %d", c, tt.wantCalls)
+			}
+
+			stored, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if stored.AccessToken != tt.want {
+				t.Errorf("stored token = %v, want %v", stored.AccessToken, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lockTokenFile(t *testing.T) {
+	useTemporaryConfigDir(t)
+
+	unlock, err := lockTokenFile("")
+	if err != nil {
+		t.Fatalf("lockTokenFile() error = %v", err)
+	}
+
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(time.Millisecond * 200)
+		close(released)
+		unlock()
+	}()
+
+	// second lock must wait for the first one to be released
+	unlock2, err := lockTokenFile("")
+	if err != nil {
+		t.Fatalf("lockTokenFile() error = %v", err)
+	}
+	defer unlock2()
+
+	select {
+	case <-released:
+	default:
+		t.Errorf("lockTokenFile() acquired before previous lock was released")
+	}
+}
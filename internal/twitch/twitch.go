@@ -2,106 +2,316 @@ package twitch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/peterbourgon/diskv/v3"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
 )
 
 const (
-	apiURL   = "https://api.twitch.tv/helix"
-	cacheDir = "Twitch Clip"
+	defaultAPIURL  = "https://api.twitch.tv/helix"
+	defaultAuthURL = "https://id.twitch.tv/oauth2"
+	cacheDir       = "Twitch Clip"
+
+	defaultMaxStreams = 1000             // default maximum number of streams read across pages
+	defaultTimeout    = time.Second * 30 // default maximum duration of each call, retries included
+
+	browserLoginTimeout    = time.Minute * 5  // time given to the user to log in through the browser
+	deviceCodeLoginTimeout = time.Minute * 30 // time given to the user to approve this device
+)
+
+var (
+	// ErrInvalidAccount is returned when the configured account cannot be used as a directory name
+	ErrInvalidAccount = errors.New("invalid account name")
+
+	accountPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{0,64}$`)
 )
 
 type Client struct {
-	httpClient *http.Client // make each Twitch requests. Requests will be authenticated
+	config     *Config
 	cache      *diskv.Diskv // store avatar
-	me         *User        // current connected user
+	cacheMutex sync.Mutex   // guards pruning and clearing the cache
+	prunedAt   time.Time    // last check of the cache limits
+
+	cacheMaxSize int64         // maximum size of the cache on disk, in bytes
+	cacheMaxAge  time.Duration // maximum age of cache entries
+	maxStreams   int           // maximum number of streams returned by a Streams call
+	baseURL      string        // Helix API URL
+	authClient   *http.Client  // unauthenticated client for the OAuth2 server
+	store        TokenStore    // saves the session between runs
+	limiter      *rateLimiter  // Helix rate limit bucket, shared by every request
+	retryDelay   time.Duration // first retry delay of failed requests
+	timeout      time.Duration // maximum duration of each call
+
+	loginMutex sync.Mutex    // one login at a time
+	rejected   chan struct{} // signaled when the session could not be refreshed after a 401
+
+	// Current session, all nil while logged out
+	session     sync.RWMutex
+	httpClient  *http.Client       // make each Twitch requests. Requests will be authenticated
+	tokenSource oauth2.TokenSource // token used by httpClient
+	me          *User              // current connected user
+	scopes      map[string]bool    // scopes granted to the session, nil while unknown
+	requested   []string           // scopes added by Reauthorize, requested on each login
 
 	// Available public methods on client
-	Streams StreamsI
-	Users   UsersI
+	Streams  StreamsI
+	Users    UsersI
+	Channels ChannelsI
+	EventSub EventSubI
+	Videos   VideosI
+	Games    GamesI
+	Clips    ClipsI
+	Schedule ScheduleI
 }
 
 type Config struct {
 	ClientID     string
-	ClientSecret string
-}
+	ClientSecret string // optional when using the Device Code flow
 
-var (
-	// Singleton
-	once   sync.Once
-	client *Client
-)
+	// DeviceCode uses the OAuth Device Code Grant flow instead of a browser on this machine.
+	// It is always used when ClientSecret is empty, the app then runs as a public client.
+	DeviceCode bool
+
+	// DeviceCodePrompt is called with each code the user must enter to approve this device, and with nil once done.
+	// The client itself may log in again when Twitch rejects its session.
+	DeviceCodePrompt func(*DeviceCode)
+
+	// MaxStreams caps the number of streams read across pages by Streams calls.
+	// Defaults to 1000 when zero or negative.
+	MaxStreams int
+
+	// Timeout is the maximum duration of each call, pages and retries included. Defaults to 30s.
+	// The context given to a call can set a shorter deadline.
+	Timeout time.Duration
+
+	// BaseURL of the Helix API. Defaults to https://api.twitch.tv/helix
+	BaseURL string
+
+	// AuthURL of the Twitch OAuth2 server. Defaults to https://id.twitch.tv/oauth2
+	AuthURL string
+
+	// HTTPClient is an already authenticated client to use. No login is performed when set.
+	HTTPClient *http.Client
+
+	// TokenSource of HTTPClient, used to validate and revoke its session
+	TokenSource oauth2.TokenSource
+
+	// TokenStore saves the session between runs. Defaults to a file only readable by the current user.
+	// A token file written by older versions is migrated to it.
+	TokenStore TokenStore
+
+	// EventSubURL of the EventSub WebSocket server. Defaults to wss://eventsub.wss.twitch.tv/ws
+	EventSubURL string
+
+	// Account namespaces the stored session and the cache, so several Twitch accounts can be used side by side.
+	// Letters, digits, '-' and '_' only. Empty is the default account.
+	Account string
+
+	// DisableLogin only uses the stored session. ErrLoggedOut is returned when there is none.
+	DisableLogin bool
+
+	// CacheMaxSize is the maximum size of the cache on disk in bytes, oldest entries being removed first.
+	// Defaults to 20 MB.
+	CacheMaxSize int64
+
+	// CacheMaxAge is the maximum age of cache entries. Defaults to 30 days.
+	CacheMaxAge time.Duration
+
+	// Scopes are requested on login along with user:read:follows, e.g. chat:read.
+	// A stored session is used as is, even if granted before a scope was added:
+	// check it with RequireScopes and ask for missing ones with Reauthorize.
+	Scopes []string
+}
 
 // New returns a new Twitch client
+// The user is logged in if no HTTPClient is configured and no session is stored
 func New(config *Config) (*Client, error) {
-	var err error
-	once.Do(func() {
-		if config == nil {
-			err = fmt.Errorf("missing Twitch config config")
-			return
-		}
+	if config == nil {
+		return nil, fmt.Errorf("missing Twitch config config")
+	}
 
-		if config.ClientID == "" {
-			err = fmt.Errorf("missing Twitch client ID. Check https://dev.twitch.tv/console/apps/create")
-			return
-		}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("missing Twitch client ID. Check https://dev.twitch.tv/console/apps/create")
+	}
 
-		if config.ClientSecret == "" {
-			err = fmt.Errorf("missing Twitch client secret. Check https://dev.twitch.tv/console/apps/create")
-			return
-		}
+	if !accountPattern.MatchString(config.Account) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAccount, config.Account)
+	}
+
+	// create the client
+	client := new(Client)
+	client.config = config
+	client.baseURL = config.baseURL()
+	client.limiter = newRateLimiter()
+	client.rejected = make(chan struct{}, 1)
+	client.retryDelay = defaultRetryDelay
+	client.timeout = config.Timeout
+	if client.timeout <= 0 {
+		client.timeout = defaultTimeout
+	}
+	client.maxStreams = config.MaxStreams
+	if client.maxStreams <= 0 {
+		client.maxStreams = defaultMaxStreams
+	}
 
-		// create the client
-		client = new(Client)
+	client.store = config.TokenStore
+	if client.store == nil {
+		client.store = NewAccountFileTokenStore(config.Account)
+	}
 
-		// create cache
-		client.cache, err = createCacheDir()
-		if err != nil {
-			err = fmt.Errorf("unable to create cache directory: %w", err)
-			return
+	if config.Account == "" {
+		if err := migrateTokenFile(client.store); err != nil {
+			log.Errorf("unable to migrate token file: %s", err)
 		}
+	}
 
-		// Wait for http.Client
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-		defer cancel()
+	client.authClient = setupHTTPClient(config.ClientID)
+	if config.HTTPClient != nil {
+		client.authClient = withClientID(config.HTTPClient, config.ClientID)
+	}
 
-		client.httpClient, err = getToken(ctx, config)
-		if err != nil {
-			return
-		}
+	// create cache
+	var err error
+	client.cache, err = createCacheDir(config.Account)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cache directory: %w", err)
+	}
 
-		// Get the original transport
-		client.Streams = &streamsClient{client}
-		client.Users = &usersClient{client}
+	client.cacheMaxSize = config.CacheMaxSize
+	if client.cacheMaxSize <= 0 {
+		client.cacheMaxSize = defaultCacheMaxSize
+	}
+	client.cacheMaxAge = config.CacheMaxAge
+	if client.cacheMaxAge <= 0 {
+		client.cacheMaxAge = defaultCacheMaxAge
+	}
 
-		// Get current connected user
-		var users []*User
-		users, err = client.Users.Get()
-		if err != nil {
-			err = fmt.Errorf("unable to initialize client: %w", err)
-			return
-		}
+	client.Streams = &streamsClient{client}
+	client.Users = &usersClient{c: client, users: make(map[string]*cachedUser)}
+	client.Channels = &channelsClient{client}
+	client.EventSub = &eventSubClient{client, config.eventSubURL()}
+	client.Videos = &videosClient{client}
+	client.Games = &gamesClient{c: client, games: make(map[string]*Game)}
+	client.Clips = &clipsClient{client}
+	client.Schedule = &scheduleClient{client}
+	client.pruneCache()
 
-		client.me = users[0]
-	})
+	if err := client.Login(context.Background()); err != nil {
+		return nil, err
+	}
 
-	return client, err
+	return client, nil
 }
 
-func createCacheDir() (*diskv.Diskv, error) {
+// useDeviceCode returns whether the Device Code Grant flow must be used to log in
+func (c *Config) useDeviceCode() bool {
+	return c.DeviceCode || c.ClientSecret == ""
+}
+
+// loginTimeout returns the time given to the user to log in
+func (c *Config) loginTimeout() time.Duration {
+	if c.useDeviceCode() {
+		return deviceCodeLoginTimeout
+	}
+
+	return browserLoginTimeout
+}
+
+// withClientID returns a copy of given http.Client sending the Client-Id header on each request
+func withClientID(httpClient *http.Client, clientID string) *http.Client {
+	original := httpClient.Transport
+	if original == nil {
+		original = http.DefaultTransport
+	}
+
+	c := *httpClient
+	c.Transport = &transport{Original: original, clientID: clientID}
+	return &c
+}
+
+// baseURL returns the Helix API URL without trailing slash
+func (c *Config) baseURL() string {
+	if c.BaseURL == "" {
+		return defaultAPIURL
+	}
+
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// eventSubURL returns the EventSub WebSocket server URL
+func (c *Config) eventSubURL() string {
+	if c.EventSubURL == "" {
+		return defaultEventSubURL
+	}
+
+	return c.EventSubURL
+}
+
+// endpoint returns the OAuth2 endpoints of the configured Twitch OAuth2 server
+func (c *Config) endpoint() oauth2.Endpoint {
+	u := strings.TrimSuffix(c.AuthURL, "/")
+	if u == "" {
+		u = defaultAuthURL
+	}
+
+	return oauth2.Endpoint{
+		AuthURL:  u + "/authorize",
+		TokenURL: u + "/token",
+	}
+}
+
+// oauth2Config returns the OAuth2 configuration of a login granting given scopes
+func (c *Config) oauth2Config(scopes []string) *oauth2.Config {
+	config := &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       scopes,
+		Endpoint:     c.endpoint(),
+		RedirectURL:  "http://" + serverAddr,
+	}
+
+	// Public clients do not have any secret to send
+	if c.useDeviceCode() {
+		config.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+
+	return config
+}
+
+// authURL returns the configured Twitch OAuth2 server URL without trailing slash
+func (c *Config) authURL() string {
+	return strings.TrimSuffix(c.endpoint().TokenURL, "/token")
+}
+
+// deviceURL returns the Device Code Grant flow endpoint of the configured Twitch OAuth2 server
+func (c *Config) deviceURL() string {
+	return c.authURL() + "/device"
+}
+
+// createCacheDir returns the cache of given account
+func createCacheDir(account string) (*diskv.Diskv, error) {
 	dir, err := os.UserCacheDir()
 	if err != nil {
 		return nil, fmt.Errorf("unable to find cache directory: %w", err)
 	}
 
+	dir = filepath.Join(dir, cacheDir)
+	if account != "" {
+		dir = filepath.Join(dir, accountsDir, account)
+	}
+
 	return diskv.New(diskv.Options{
-		BasePath:     filepath.Join(dir, cacheDir),
+		BasePath:     dir,
 		CacheSizeMax: 20 * 10 * 1024, // 20 images, each image is 10 KB
 		PathPerm:     0755,
 		FilePerm:     0644,
@@ -2,31 +2,129 @@ package twitch
 
 import (
 	"net/http"
-	"reflect"
 	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+	"golang.org/x/oauth2"
 )
 
+// useTemporaryCacheDir makes os.UserCacheDir return a temporary directory for the current test
+func useTemporaryCacheDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+}
+
+// newTestServer returns a fake Helix server with an authenticated user
+func newTestServer(t *testing.T) *twitchtest.Server {
+	t.Helper()
+	srv := twitchtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddUser(&twitchtest.User{ID: "1", Login: "foo", DisplayName: "Foo"})
+	return srv
+}
+
+// newTestClient returns a Client connected to given fake Helix server
+func newTestClient(t *testing.T, srv *twitchtest.Server) *Client {
+	t.Helper()
+	useTemporaryCacheDir(t)
+	useTemporaryConfigDir(t) // sessions are removed from disk on logout
+
+	c, err := New(&Config{
+		ClientID:    twitchtest.ClientID,
+		BaseURL:     srv.URL,
+		AuthURL:     srv.URL + "/oauth2",
+		HTTPClient:  srv.Client(),
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: twitchtest.AccessToken}),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	c.retryDelay = time.Millisecond
+	return c
+}
+
 func TestNew(t *testing.T) {
+	srv := newTestServer(t)
+	failing := newTestServer(t)
+	failing.Fail("/users", http.StatusUnauthorized, "Invalid OAuth token")
+
 	type args struct {
 		config *Config
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    *Client
+		want    string // login of the connected user
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "Expected error without config",
+			args:    args{config: nil},
+			wantErr: true,
+		},
+		{
+			name:    "Expected error without client ID",
+			args:    args{config: &Config{HTTPClient: srv.Client(), BaseURL: srv.URL}},
+			wantErr: true,
+		},
+		{
+			name: "Expected",
+			args: args{config: &Config{
+				ClientID:   twitchtest.ClientID,
+				BaseURL:    srv.URL,
+				HTTPClient: srv.Client(),
+			}},
+			want:    "foo",
+			wantErr: false,
+		},
+		{
+			name: "Expected error on invalid account",
+			args: args{config: &Config{
+				ClientID:   twitchtest.ClientID,
+				BaseURL:    srv.URL,
+				HTTPClient: srv.Client(),
+				Account:    "../foo",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Expected error without stored session when login is disabled",
+			args: args{config: &Config{
+				ClientID:     twitchtest.ClientID,
+				BaseURL:      srv.URL,
+				AuthURL:      srv.URL + "/oauth2",
+				Account:      "bar",
+				DisableLogin: true,
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Expected error when current user cannot be read",
+			args: args{config: &Config{
+				ClientID:   twitchtest.ClientID,
+				BaseURL:    failing.URL,
+				HTTPClient: failing.Client(),
+			}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			useTemporaryCacheDir(t)
+			useTemporaryConfigDir(t)
 			got, err := New(tt.args.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("New() got = %v, want %v", got, tt.want)
+			if err == nil && got.Users.Me().Login != tt.want {
+				t.Errorf("New() got = %v, want %v", got.Users.Me().Login, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,261 @@
+package twitchtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	eventSubPath              = "/eventsub/ws"
+	defaultEventSubKeepalive  = 10 // seconds
+	eventSubReconnectQueryKey = "reconnect"
+)
+
+// Event describes an EventSub notification event sent by the fake server
+type Event struct {
+	BroadcasterUserID    string    `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string    `json:"broadcaster_user_login"`
+	BroadcasterUserName  string    `json:"broadcaster_user_name"`
+	Type                 string    `json:"type,omitempty"`
+	StartedAt            time.Time `json:"started_at,omitempty"`
+	Title                string    `json:"title,omitempty"`
+	Language             string    `json:"language,omitempty"`
+	CategoryID           string    `json:"category_id,omitempty"`
+	CategoryName         string    `json:"category_name,omitempty"`
+
+	ContentClassificationLabels []string `json:"content_classification_labels,omitempty"`
+}
+
+// Subscription describes an EventSub subscription created on the fake server
+type Subscription struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Version   string `json:"version"`
+	Status    string `json:"status"`
+	Condition struct {
+		BroadcasterUserID string `json:"broadcaster_user_id"`
+	} `json:"condition"`
+	Transport struct {
+		Method    string `json:"method"`
+		SessionID string `json:"session_id"`
+	} `json:"transport"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// eventSub carries the EventSub WebSocket stand-in state
+type eventSub struct {
+	keepalive     int // seconds
+	limit         int // maximum number of subscriptions, unlimited when zero
+	sessions      map[string]*websocket.Conn
+	subscriptions []*Subscription
+	counter       int // used to generate IDs
+}
+
+func (s *Server) registerEventSub(mux *http.ServeMux) {
+	s.eventSub = &eventSub{
+		keepalive: defaultEventSubKeepalive,
+		sessions:  make(map[string]*websocket.Conn),
+	}
+
+	mux.Handle(eventSubPath, websocket.Handler(s.handleEventSubConn))
+	mux.HandleFunc("/eventsub/subscriptions", s.handle(s.handleSubscriptions))
+}
+
+// EventSubURL returns the WebSocket URL of the EventSub stand-in
+func (s *Server) EventSubURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + eventSubPath
+}
+
+// SetKeepalive sets the keepalive timeout announced in welcome messages, in seconds
+func (s *Server) SetKeepalive(seconds int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.eventSub.keepalive = seconds
+}
+
+// SetSubscriptionLimit makes subscriptions fail with 429 once n subscriptions exist. Zero means unlimited.
+func (s *Server) SetSubscriptionLimit(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.eventSub.limit = n
+}
+
+// Subscriptions returns the enabled subscriptions
+func (s *Server) Subscriptions() []Subscription {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subscriptions := make([]Subscription, 0, len(s.eventSub.subscriptions))
+	for _, sub := range s.eventSub.subscriptions {
+		subscriptions = append(subscriptions, *sub)
+	}
+
+	return subscriptions
+}
+
+// SendEvent sends a notification to each session subscribed to subscriptionType for the event broadcaster.
+// It returns the number of notifications sent.
+func (s *Server) SendEvent(subscriptionType string, event *Event) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var sent int
+	for _, sub := range s.eventSub.subscriptions {
+		if sub.Type != subscriptionType || sub.Condition.BroadcasterUserID != event.BroadcasterUserID {
+			continue
+		}
+
+		conn, ok := s.eventSub.sessions[sub.Transport.SessionID]
+		if !ok {
+			continue
+		}
+
+		payload := map[string]interface{}{"subscription": sub, "event": event}
+		if err := s.writeEventSubMessage(conn, "notification", sub, payload); err == nil {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+// SendReconnect asks every connected session to reconnect to a new URL, keeping its subscriptions
+func (s *Server) SendReconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, conn := range s.eventSub.sessions {
+		payload := map[string]interface{}{"session": map[string]interface{}{
+			"id":            id,
+			"status":        "reconnecting",
+			"reconnect_url": s.EventSubURL() + "?" + eventSubReconnectQueryKey + "=" + id,
+		}}
+		_ = s.writeEventSubMessage(conn, "session_reconnect", nil, payload)
+	}
+}
+
+// CloseSessions drops every EventSub connection, as a network failure would
+func (s *Server) CloseSessions() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, conn := range s.eventSub.sessions {
+		_ = conn.Close()
+	}
+}
+
+// handleEventSubConn welcomes a new session and keeps it open until the client leaves
+func (s *Server) handleEventSubConn(conn *websocket.Conn) {
+	s.mutex.Lock()
+	s.eventSub.counter++
+	id := fmt.Sprintf("session-%d", s.eventSub.counter)
+	s.eventSub.sessions[id] = conn
+
+	payload := map[string]interface{}{"session": map[string]interface{}{
+		"id":                        id,
+		"status":                    "connected",
+		"keepalive_timeout_seconds": s.eventSub.keepalive,
+		"connected_at":              time.Now(),
+	}}
+	err := s.writeEventSubMessage(conn, "session_welcome", nil, payload)
+
+	// Subscriptions of the previous session move to the new one, the previous one is then closed
+	if previous := conn.Request().URL.Query().Get(eventSubReconnectQueryKey); previous != "" && err == nil {
+		for _, sub := range s.eventSub.subscriptions {
+			if sub.Transport.SessionID == previous {
+				sub.Transport.SessionID = id
+			}
+		}
+
+		if old, ok := s.eventSub.sessions[previous]; ok {
+			_ = old.Close()
+		}
+	}
+	s.mutex.Unlock()
+	if err != nil {
+		return
+	}
+
+	// Clients never send anything, wait for them to leave
+	var msg string
+	for websocket.Message.Receive(conn, &msg) == nil {
+	}
+
+	// Subscriptions of a closed session are disabled
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.eventSub.sessions, id)
+
+	var subscriptions []*Subscription
+	for _, sub := range s.eventSub.subscriptions {
+		if sub.Transport.SessionID != id {
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+	s.eventSub.subscriptions = subscriptions
+}
+
+func (s *Server) handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, &Error{Err: "Method Not Allowed", Status: http.StatusMethodNotAllowed})
+		return
+	}
+
+	sub := new(Subscription)
+	if err := json.NewDecoder(r.Body).Decode(sub); err != nil {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	if _, ok := s.eventSub.sessions[sub.Transport.SessionID]; !ok || sub.Transport.Method != "websocket" {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "websocket transport session does not exist or has already disconnected"})
+		return
+	}
+
+	for _, existing := range s.eventSub.subscriptions {
+		if existing.Type == sub.Type && existing.Condition == sub.Condition && existing.Transport == sub.Transport {
+			writeError(w, &Error{Err: "Conflict", Status: http.StatusConflict, Message: "subscription already exists"})
+			return
+		}
+	}
+
+	if s.eventSub.limit > 0 && len(s.eventSub.subscriptions) >= s.eventSub.limit {
+		writeError(w, &Error{Err: "Too Many Requests", Status: http.StatusTooManyRequests, Message: "websocket transport cost exceeded"})
+		return
+	}
+
+	s.eventSub.counter++
+	sub.ID = fmt.Sprintf("subscription-%d", s.eventSub.counter)
+	sub.Status = "enabled"
+	sub.CreatedAt = time.Now()
+	s.eventSub.subscriptions = append(s.eventSub.subscriptions, sub)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"data":           []*Subscription{sub},
+		"total":          len(s.eventSub.subscriptions),
+		"total_cost":     0,
+		"max_total_cost": s.eventSub.limit,
+	})
+}
+
+// writeEventSubMessage sends a message of given type. The caller must hold the mutex.
+func (s *Server) writeEventSubMessage(conn *websocket.Conn, messageType string, sub *Subscription, payload interface{}) error {
+	s.eventSub.counter++
+	metadata := map[string]string{
+		"message_id":        fmt.Sprintf("message-%d", s.eventSub.counter),
+		"message_type":      messageType,
+		"message_timestamp": time.Now().Format(time.RFC3339Nano),
+	}
+
+	if sub != nil {
+		metadata["subscription_type"] = sub.Type
+		metadata["subscription_version"] = sub.Version
+	}
+
+	return websocket.JSON.Send(conn, map[string]interface{}{"metadata": metadata, "payload": payload})
+}
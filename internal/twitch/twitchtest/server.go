@@ -0,0 +1,846 @@
+// Package twitchtest provides an in-process fake Helix server to test Twitch clients offline.
+package twitchtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// ClientID accepted by the fake server
+	ClientID = "twitchtest"
+
+	// AccessToken of the authenticated user, as validated and revoked by the fake OAuth2 server
+	AccessToken = "twitchtest-token"
+
+	defaultPageSize = 20 // Twitch default when "first" is not set
+)
+
+// User describes a Twitch user served by the fake server
+type User struct {
+	ID              string    `json:"id"`
+	Login           string    `json:"login"`
+	DisplayName     string    `json:"display_name"`
+	Type            string    `json:"type"`
+	BroadcasterType string    `json:"broadcaster_type"`
+	Description     string    `json:"description"`
+	ProfileImageURL string    `json:"profile_image_url"`
+	OfflineImageURL string    `json:"offline_image_url"`
+	ViewCount       int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Stream describes a live stream served by the fake server
+type Stream struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameID       string    `json:"game_id"`
+	GameName     string    `json:"game_name"`
+	Type         string    `json:"type"`
+	Title        string    `json:"title"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	Language     string    `json:"language"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	IsMature     bool      `json:"is_mature"`
+	Tags         []string  `json:"tags"`
+}
+
+// Channel describes the information of a channel served by the fake server
+type Channel struct {
+	BroadcasterID               string   `json:"broadcaster_id"`
+	BroadcasterLogin            string   `json:"broadcaster_login"`
+	BroadcasterName             string   `json:"broadcaster_name"`
+	BroadcasterLanguage         string   `json:"broadcaster_language"`
+	Title                       string   `json:"title"`
+	Tags                        []string `json:"tags"`
+	ContentClassificationLabels []string `json:"content_classification_labels"`
+}
+
+// Game describes a category served by the fake server
+type Game struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+	IgdbID    string `json:"igdb_id"`
+}
+
+// Clip describes a clip served by the fake server
+type Clip struct {
+	ID              string    `json:"id"`
+	URL             string    `json:"url"`
+	EmbedURL        string    `json:"embed_url"`
+	BroadcasterID   string    `json:"broadcaster_id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatorID       string    `json:"creator_id"`
+	CreatorName     string    `json:"creator_name"`
+	VideoID         string    `json:"video_id"`
+	GameID          string    `json:"game_id"`
+	Language        string    `json:"language"`
+	Title           string    `json:"title"`
+	ViewCount       int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	Duration        float64   `json:"duration"`
+}
+
+// Video describes a video served by the fake server
+type Video struct {
+	ID           string    `json:"id"`
+	StreamID     string    `json:"stream_id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	PublishedAt  time.Time `json:"published_at"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Viewable     string    `json:"viewable"`
+	ViewCount    int       `json:"view_count"`
+	Language     string    `json:"language"`
+	Type         string    `json:"type"`
+	Duration     string    `json:"duration"`
+}
+
+// Segment describes a scheduled broadcast served by the fake server
+type Segment struct {
+	ID            string           `json:"id"`
+	StartTime     time.Time        `json:"start_time"`
+	EndTime       time.Time        `json:"end_time"`
+	Title         string           `json:"title"`
+	CanceledUntil *time.Time       `json:"canceled_until"`
+	Category      *SegmentCategory `json:"category"`
+	IsRecurring   bool             `json:"is_recurring"`
+}
+
+// SegmentCategory is the category of a scheduled broadcast
+type SegmentCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Error describes a Twitch error response
+type Error struct {
+	Err     string `json:"error"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// Server is a fake Helix server. Use its URL as twitch.Config BaseURL and its Client as HTTPClient.
+// Each method is safe to call while the server is running.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	me       string                // ID of the authenticated user
+	users    []*User               // every known user
+	streams  []*Stream             // every live stream, sorted as added
+	channels []*Channel            // channel information set by AddChannel
+	follows  []*Follow             // channels followed by me, most recent first
+	videos   []*Video              // every video, most recent first
+	games    []*Game               // every category
+	clips    []*Clip               // every clip, most viewed first
+	segments map[string][]*Segment // scheduled broadcasts by broadcaster ID, sorted by start time
+	failures map[string]*failure   // forced errors by path
+	overlap  bool                  // repeat the last item of the previous page
+	pageSize int                   // maximum page size, regardless of "first"
+	requests map[string]int        // number of requests by path
+	revoked  bool                  // AccessToken is not valid anymore
+	scopes   []string              // scopes granted to AccessToken
+	latency  time.Duration         // time taken to answer each request
+
+	eventSub  *eventSub
+	rateLimit *rateLimit
+}
+
+// failure is a forced error response
+type failure struct {
+	err   *Error
+	times int // remaining number of failures, unlimited when zero
+}
+
+// rateLimit is a Helix rate limit bucket
+type rateLimit struct {
+	limit     int
+	window    time.Duration
+	remaining int
+	reset     time.Time
+}
+
+// Follow describes a channel followed by the authenticated user
+type Follow struct {
+	BroadcasterID    string    `json:"broadcaster_id"`
+	BroadcasterLogin string    `json:"broadcaster_login"`
+	BroadcasterName  string    `json:"broadcaster_name"`
+	FollowedAt       time.Time `json:"followed_at"`
+}
+
+// NewServer starts a fake Helix server, it must be closed when done
+func NewServer() *Server {
+	s := &Server{
+		failures: make(map[string]*failure),
+		requests: make(map[string]int),
+		segments: make(map[string][]*Segment),
+		pageSize: 100,
+		scopes:   []string{"user:read:follows"},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/streams", s.handle(s.handleStreams))
+	mux.HandleFunc("/streams/followed", s.handle(s.handleFollowedStreams))
+	mux.HandleFunc("/users", s.handle(s.handleUsers))
+	mux.HandleFunc("/channels", s.handle(s.handleChannels))
+	mux.HandleFunc("/channels/followed", s.handle(s.handleFollowedChannels))
+	mux.HandleFunc("/videos", s.handle(s.handleVideos))
+	mux.HandleFunc("/games", s.handle(s.handleGames))
+	mux.HandleFunc("/clips", s.handle(s.handleClips))
+	mux.HandleFunc("/schedule", s.handle(s.handleSchedule))
+	mux.HandleFunc("/boxart/", s.handle(s.handleAvatar))
+	mux.HandleFunc("/avatars/", s.handle(s.handleAvatar))
+	mux.HandleFunc("/previews/", s.handle(s.handleAvatar))
+	mux.HandleFunc("/oauth2/validate", s.handle(s.handleValidate))
+	mux.HandleFunc("/oauth2/revoke", s.handle(s.handleRevoke))
+	s.registerEventSub(mux)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddUser registers users. The first user added is the authenticated one, unless SetMe is used.
+// An avatar is served for users without ProfileImageURL.
+func (s *Server) AddUser(users ...*User) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, u := range users {
+		if u.ProfileImageURL == "" {
+			u.ProfileImageURL = s.URL + "/avatars/" + u.Login
+		}
+
+		if s.me == "" {
+			s.me = u.ID
+		}
+
+		s.users = append(s.users, u)
+	}
+}
+
+// SetMe sets the authenticated user ID
+func (s *Server) SetMe(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.me = id
+}
+
+// AddStream marks given streams as live. A preview is served for streams without ThumbnailURL.
+func (s *Server) AddStream(streams ...*Stream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, stream := range streams {
+		if stream.ThumbnailURL == "" {
+			stream.ThumbnailURL = s.URL + "/previews/live_user_" + stream.UserLogin + "-{width}x{height}.jpg"
+		}
+	}
+
+	s.streams = append(s.streams, streams...)
+}
+
+// RemoveStream marks streams of given user ID as offline
+func (s *Server) RemoveStream(userID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var streams []*Stream
+	for _, stream := range s.streams {
+		if stream.UserID != userID {
+			streams = append(streams, stream)
+		}
+	}
+
+	s.streams = streams
+}
+
+// Follow makes the authenticated user follow given broadcaster IDs.
+// Broadcasters registered with AddUser get their login and display name filled.
+func (s *Server) Follow(broadcasterIDs ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, id := range broadcasterIDs {
+		if s.follow(id) != nil {
+			continue
+		}
+
+		f := &Follow{BroadcasterID: id, FollowedAt: time.Now()}
+		for _, u := range s.users {
+			if u.ID == id {
+				f.BroadcasterLogin = u.Login
+				f.BroadcasterName = u.DisplayName
+			}
+		}
+
+		s.follows = append([]*Follow{f}, s.follows...)
+	}
+}
+
+// follow returns the follow of given broadcaster ID, if any
+func (s *Server) follow(broadcasterID string) *Follow {
+	for _, f := range s.follows {
+		if f.BroadcasterID == broadcasterID {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// AddChannel sets the information of channels. Channels of other users are served with their login and name only.
+func (s *Server) AddChannel(channels ...*Channel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.channels = append(s.channels, channels...)
+}
+
+// AddGame registers categories. A box art is served for games without BoxArtURL.
+func (s *Server) AddGame(games ...*Game) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, g := range games {
+		if g.BoxArtURL == "" {
+			g.BoxArtURL = s.URL + "/boxart/" + g.ID + "-{width}x{height}.jpg"
+		}
+
+		s.games = append(s.games, g)
+	}
+}
+
+// AddClip adds clips, less viewed than the ones already added
+func (s *Server) AddClip(clips ...*Clip) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clips = append(s.clips, clips...)
+}
+
+// AddSegment adds scheduled broadcasts to the schedule of given broadcaster
+func (s *Server) AddSegment(broadcasterID string, segments ...*Segment) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedule := append(s.segments[broadcasterID], segments...)
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].StartTime.Before(schedule[j].StartTime)
+	})
+
+	s.segments[broadcasterID] = schedule
+}
+
+// AddVideo adds videos, more recent than the ones already added
+func (s *Server) AddVideo(videos ...*Video) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, v := range videos {
+		s.videos = append([]*Video{v}, s.videos...)
+	}
+}
+
+// Fail makes every request to path fail with given status and message. A zero status clears it.
+func (s *Server) Fail(path string, status int, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if status == 0 {
+		delete(s.failures, path)
+		return
+	}
+
+	s.failures[path] = &failure{err: &Error{Err: http.StatusText(status), Status: status, Message: message}}
+}
+
+// FailTimes makes the next n requests to path fail with given status and message
+func (s *Server) FailTimes(path string, status int, message string, n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[path] = &failure{err: &Error{Err: http.StatusText(status), Status: status, Message: message}, times: n}
+}
+
+// SetRateLimit enables a rate limit bucket of limit points refilled every window.
+// Rate limit headers are sent on each response and requests fail with 429 once the bucket is empty.
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rateLimit = &rateLimit{limit: limit, window: window, remaining: limit, reset: nextReset(window)}
+}
+
+// SetLatency delays each response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency = d
+}
+
+// SetPageSize caps the number of items per page, to exercise pagination
+func (s *Server) SetPageSize(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pageSize = n
+}
+
+// SetOverlap makes each page start with the last item of the previous one,
+// as Twitch does when viewers join and leave streams between two pages
+func (s *Server) SetOverlap(overlap bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.overlap = overlap
+}
+
+// RevokeToken makes AccessToken invalid, as when the user disconnects the app from Twitch
+func (s *Server) RevokeToken() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.revoked = true
+}
+
+// SetScopes sets the scopes granted to AccessToken, user:read:follows by default
+func (s *Server) SetScopes(scopes ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.scopes = scopes
+}
+
+// Revoked returns whether AccessToken has been revoked
+func (s *Server) Revoked() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.revoked
+}
+
+// Requests returns the number of requests received on path
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+// handle counts requests, checks the Client-Id header and applies forced failures
+func (s *Server) handle(next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.requests[r.URL.Path]++
+		forced := s.failures[r.URL.Path]
+		if forced != nil && forced.times > 0 {
+			if forced.times--; forced.times == 0 {
+				delete(s.failures, r.URL.Path)
+			}
+		}
+		limited := s.consumeRateLimit(w)
+		latency := s.latency
+		s.mutex.Unlock()
+
+		select {
+		case <-r.Context().Done():
+			return // client gave up
+		case <-time.After(latency):
+		}
+
+		switch {
+		case r.Header.Get("Client-Id") == "":
+			writeError(w, &Error{Err: "Unauthorized", Status: http.StatusUnauthorized, Message: "Client ID is missing"})
+		case limited:
+			writeError(w, &Error{Err: "Too Many Requests", Status: http.StatusTooManyRequests, Message: "rate limit exceeded"})
+		case forced != nil:
+			writeError(w, forced.err)
+		default:
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			next(w, r)
+		}
+	}
+}
+
+// consumeRateLimit takes a point from the bucket and writes the rate limit headers.
+// It returns true when the bucket is empty. The caller must hold the mutex.
+func (s *Server) consumeRateLimit(w http.ResponseWriter) bool {
+	if s.rateLimit == nil {
+		return false
+	}
+
+	b := s.rateLimit
+	if time.Now().After(b.reset) {
+		b.remaining = b.limit
+		b.reset = nextReset(b.window)
+	}
+
+	limited := b.remaining == 0
+	if !limited {
+		b.remaining--
+	}
+
+	w.Header().Set("Ratelimit-Limit", strconv.Itoa(b.limit))
+	w.Header().Set("Ratelimit-Remaining", strconv.Itoa(b.remaining))
+	w.Header().Set("Ratelimit-Reset", strconv.FormatInt(b.reset.Unix(), 10))
+	return limited
+}
+
+// nextReset returns the next bucket refill time, rounded up to the second as sent in headers
+func nextReset(window time.Duration) time.Time {
+	return time.Unix(time.Now().Add(window).Unix()+1, 0)
+}
+
+func (s *Server) handleStreams(w http.ResponseWriter, r *http.Request) {
+	logins := r.URL.Query()["user_login"]
+	var streams []*Stream
+	for _, stream := range s.streams {
+		if len(logins) == 0 || contains(logins, stream.UserLogin) {
+			streams = append(streams, stream)
+		}
+	}
+
+	writePage(w, r, s.pageSize, s.overlap, streams)
+}
+
+func (s *Server) handleFollowedStreams(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("user_id") != s.me {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "The ID in user_id must match the user ID found in the request's OAuth token."})
+		return
+	}
+
+	var streams []*Stream
+	for _, stream := range s.streams {
+		if s.follow(stream.UserID) != nil {
+			streams = append(streams, stream)
+		}
+	}
+
+	writePage(w, r, s.pageSize, s.overlap, streams)
+}
+
+func (s *Server) handleFollowedChannels(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("user_id") != s.me {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "The ID in user_id must match the user ID found in the request's OAuth token."})
+		return
+	}
+
+	writePage(w, r, s.pageSize, s.overlap, s.follows)
+}
+
+func (s *Server) handleVideos(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	videoType := r.URL.Query().Get("type")
+	if userID == "" {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Missing required parameter"})
+		return
+	}
+
+	videos := make([]*Video, 0)
+	for _, v := range s.videos {
+		if v.UserID == userID && (videoType == "" || videoType == "all" || v.Type == videoType) {
+			videos = append(videos, v)
+		}
+	}
+
+	writePage(w, r, s.pageSize, s.overlap, videos)
+}
+
+func (s *Server) handleClips(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	broadcasterID := q.Get("broadcaster_id")
+	if broadcasterID == "" {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Missing required parameter"})
+		return
+	}
+
+	var startedAt, endedAt time.Time
+	for name, t := range map[string]*time.Time{"started_at": &startedAt, "ended_at": &endedAt} {
+		if v := q.Get(name); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Invalid " + name})
+				return
+			}
+		}
+	}
+
+	clips := make([]*Clip, 0)
+	for _, c := range s.clips {
+		switch {
+		case c.BroadcasterID != broadcasterID:
+		case !startedAt.IsZero() && c.CreatedAt.Before(startedAt):
+		case !endedAt.IsZero() && c.CreatedAt.After(endedAt):
+		default:
+			clips = append(clips, c)
+		}
+	}
+
+	writePage(w, r, s.pageSize, s.overlap, clips)
+}
+
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	broadcasterID := q.Get("broadcaster_id")
+	if broadcasterID == "" {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Missing required parameter"})
+		return
+	}
+
+	first, _ := strconv.Atoi(q.Get("first"))
+	if first > 25 {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "first must be at most 25"})
+		return
+	}
+
+	if first <= 0 {
+		first = 20
+	}
+
+	var startTime time.Time
+	if v := q.Get("start_time"); v != "" {
+		var err error
+		if startTime, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Invalid start_time"})
+			return
+		}
+	}
+
+	schedule, ok := s.segments[broadcasterID]
+	if !ok {
+		writeError(w, &Error{Err: "Not Found", Status: http.StatusNotFound, Message: "segments were either not found or not available"})
+		return
+	}
+
+	segments := make([]*Segment, 0)
+	for _, segment := range schedule {
+		if len(segments) < first && !segment.StartTime.Before(startTime) {
+			segments = append(segments, segment)
+		}
+	}
+
+	data := map[string]interface{}{"segments": segments, "broadcaster_id": broadcasterID}
+	for _, u := range s.users {
+		if u.ID == broadcasterID {
+			data["broadcaster_login"] = u.Login
+			data["broadcaster_name"] = u.DisplayName
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "pagination": map[string]string{}})
+}
+
+func (s *Server) handleGames(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id"]
+	if len(ids) > 100 {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "The maximum number of IDs you may specify is 100."})
+		return
+	}
+
+	games := make([]*Game, 0)
+	for _, g := range s.games {
+		if contains(ids, g.ID) {
+			games = append(games, g)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": games})
+}
+
+func (s *Server) handleChannels(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["broadcaster_id"]
+	if len(ids) == 0 || len(ids) > 100 {
+		writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "Between 1 and 100 broadcaster IDs must be specified."})
+		return
+	}
+
+	channels := make([]*Channel, 0)
+	for _, id := range ids {
+		channels = append(channels, s.channel(id)...)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": channels})
+}
+
+// channel returns the channel of given broadcaster ID, none if unknown. The caller must hold the mutex.
+func (s *Server) channel(id string) []*Channel {
+	for _, c := range s.channels {
+		if c.BroadcasterID == id {
+			return []*Channel{c}
+		}
+	}
+
+	for _, u := range s.users {
+		if u.ID == id {
+			return []*Channel{{BroadcasterID: u.ID, BroadcasterLogin: u.Login, BroadcasterName: u.DisplayName}}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	logins := r.URL.Query()["login"]
+	ids := r.URL.Query()["id"]
+
+	users := make([]*User, 0)
+	for _, u := range s.users {
+		switch {
+		case len(logins) == 0 && len(ids) == 0 && u.ID == s.me:
+			users = append(users, u)
+		case contains(logins, u.Login), contains(ids, u.ID):
+			users = append(users, u)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": users})
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if s.revoked || r.Header.Get("Authorization") != "OAuth "+AccessToken {
+		writeError(w, &Error{Status: http.StatusUnauthorized, Message: "invalid access token"})
+		return
+	}
+
+	var login string
+	for _, u := range s.users {
+		if u.ID == s.me {
+			login = u.Login
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"client_id":  ClientID,
+		"login":      login,
+		"user_id":    s.me,
+		"scopes":     s.scopes,
+		"expires_in": 3600,
+	})
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method != http.MethodPost:
+		writeError(w, &Error{Err: "Method Not Allowed", Status: http.StatusMethodNotAllowed, Message: "POST only"})
+	case r.PostFormValue("client_id") != ClientID:
+		writeError(w, &Error{Status: http.StatusNotFound, Message: "client does not exist"})
+	case s.revoked || r.PostFormValue("token") != AccessToken:
+		writeError(w, &Error{Status: http.StatusBadRequest, Message: "Invalid token"})
+	default:
+		s.revoked = true
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// webpImage is a 1x1 lossless WebP image, the standard library has no WebP encoder
+var webpImage, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+// handleAvatar serves a small image, as avatar or box art.
+// It is a PNG image unless the path ends with .gif or .webp, paths ending with .svg serve an unsupported format.
+func (s *Server) handleAvatar(w http.ResponseWriter, r *http.Request) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{R: 0x91, G: 0x46, B: 0xff, A: 0xff})
+		}
+	}
+
+	switch path.Ext(r.URL.Path) {
+	case ".gif":
+		w.Header().Set("Content-Type", "image/gif")
+		_ = gif.Encode(w, img, nil)
+	case ".webp":
+		w.Header().Set("Content-Type", "image/webp")
+		_, _ = w.Write(webpImage)
+	case ".svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		_, _ = io.WriteString(w, `<svg xmlns="http://www.w3.org/2000/svg" width="64" height="64"/>`)
+	default:
+		w.Header().Set("Content-Type", "image/png")
+		_ = png.Encode(w, img)
+	}
+}
+
+// writePage writes the requested page of items using "first" and "after" parameters.
+// Cursors are offsets in items.
+func writePage[T any](w http.ResponseWriter, r *http.Request, pageSize int, overlap bool, items []T) {
+	first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+	if first <= 0 {
+		first = defaultPageSize
+	}
+
+	if first > pageSize {
+		first = pageSize
+	}
+
+	offset := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		var err error
+		if offset, err = strconv.Atoi(after); err != nil {
+			writeError(w, &Error{Err: "Bad Request", Status: http.StatusBadRequest, Message: "invalid cursor"})
+			return
+		}
+
+		if overlap && offset > 0 && first > 1 {
+			offset--
+		}
+	}
+
+	if offset > len(items) {
+		offset = len(items)
+	}
+
+	end := offset + first
+	if end > len(items) {
+		end = len(items)
+	}
+
+	pagination := map[string]string{}
+	if end < len(items) {
+		pagination["cursor"] = strconv.Itoa(end)
+	}
+
+	page := items[offset:end]
+	if page == nil {
+		page = make([]T, 0)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": page, "pagination": pagination})
+}
+
+func writeError(w http.ResponseWriter, e *Error) {
+	writeJSON(w, e.Status, e)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		panic(fmt.Sprintf("twitchtest: unable to encode response: %s", err))
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
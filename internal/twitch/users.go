@@ -1,29 +1,33 @@
 package twitch
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
-	"io"
-	"net/http"
-	"runtime"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
-	ico "github.com/Kodeworks/golang-image-ico"
-	"github.com/nfnt/resize"
 	log "github.com/sirupsen/logrus"
 )
 
-const usersURI = "/users"
+const (
+	usersURI         = "/users"
+	profileImageSize = 32 // size of the icon made from profile images
+
+	maxUsersPerRequest = 100                   // logins accepted by each Get Users request
+	userBatchWindow    = time.Millisecond * 50 // lookups made within this delay are requested at once
+	userTTL            = time.Hour             // users are requested again after this delay
+)
 
 var _ UsersI = (*usersClient)(nil)
 
 var (
 	ErrTooManyLoginNames = errors.New("too many login sets. Cannot be more than 100")
+
+	// ErrUserNotFound is returned by Resolve when Twitch does not know the login
+	ErrUserNotFound = errors.New("user not found")
 )
 
 type usersResponse struct {
@@ -49,163 +53,191 @@ type UsersI interface {
 	// Users are identified by optional user IDs and/or login name.
 	// If neither a user ID nor a login name is specified, the user is looked up by Bearer token.
 	// https://dev.twitch.tv/docs/api/reference#get-users
-	Get(login ...string) ([]*User, error)
+	Get(ctx context.Context, login ...string) ([]*User, error)
+
+	// Resolve returns the user of given login.
+	// Lookups made within a short window are sent as a single request, and users are remembered for an hour.
+	// ErrUserNotFound is returned for an unknown login.
+	Resolve(ctx context.Context, login string) (*User, error)
 
 	// ProfileImageBytes load the given profile from URL
-	// Reduce its size and send it as bytes
-	ProfileImageBytes(user *User) ([]byte, error)
+	// Reduce its size and send it as bytes. Images are cached by URL, a changed avatar is downloaded again.
+	// The initials of the user are returned when the image is missing or its format is not supported.
+	ProfileImageBytes(ctx context.Context, user *User) ([]byte, error)
+
+	// ProfileImagePath downloads the profile image of given user as is and returns the path of its file, e.g. for notifications
+	ProfileImagePath(ctx context.Context, user *User) (string, error)
 
-	// Me returns current connected user
+	// Me returns current connected user, nil while logged out
 	Me() *User
 }
 
 type usersClient struct {
 	c *Client
+
+	mutex   sync.Mutex
+	users   map[string]*cachedUser // by login
+	pending *userBatch             // lookups waiting to be requested
 }
 
-func (u *usersClient) Get(login ...string) ([]*User, error) {
+// cachedUser is a user remembered until userTTL
+type cachedUser struct {
+	user   *User
+	readAt time.Time
+}
+
+// userBatch is a set of lookups sent as a single request
+type userBatch struct {
+	logins []string
+	wanted map[string]bool
+	done   chan struct{} // closed once users or err is set
+	users  map[string]*User
+	err    error
+}
+
+func (u *usersClient) Get(ctx context.Context, login ...string) ([]*User, error) {
 	if len(login) > 100 {
 		return nil, ErrTooManyLoginNames
 	}
 
-	ul := apiURL + usersURI
-	req, err := http.NewRequest(http.MethodGet, ul, nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to make request: %w", err)
-	}
-
 	// Specify wanted users
-	q := req.URL.Query()
+	q := make(url.Values)
 	for _, u := range login {
 		q.Add("login", u)
 	}
-	req.URL.RawQuery = q.Encode()
 
-	resp, err := u.c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("twitch error: %v", err)
+	data := new(usersResponse)
+	if err := u.c.get(ctx, u.c.baseURL+usersURI, q, data); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	u.mutex.Lock()
+	for _, user := range data.Data {
+		u.users[user.Login] = &cachedUser{user: user, readAt: time.Now()}
 	}
+	u.mutex.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		// try to read Twitch error
-		e := new(Error)
-		if err := json.Unmarshal(body, e); err == nil {
-			return nil, e
-		}
+	return data.Data, nil
+}
+
+func (u *usersClient) Resolve(ctx context.Context, login string) (*User, error) {
+	login = strings.ToLower(login)
 
-		return nil, fmt.Errorf("twitch error: %s", body)
+	u.mutex.Lock()
+	if cached, ok := u.users[login]; ok && time.Since(cached.readAt) < userTTL {
+		u.mutex.Unlock()
+		return cached.user, nil
 	}
 
-	data := new(usersResponse)
-	if err := json.Unmarshal(body, data); err != nil {
-		return nil, fmt.Errorf("unable to read response body: %w", err)
+	batch := u.pending
+	if batch == nil {
+		batch = &userBatch{wanted: make(map[string]bool), done: make(chan struct{})}
+		u.pending = batch
+		time.AfterFunc(userBatchWindow, func() { u.flush(batch) })
 	}
 
-	return data.Data, nil
-}
+	if !batch.wanted[login] {
+		batch.wanted[login] = true
+		batch.logins = append(batch.logins, login)
+	}
 
-func (u *usersClient) ProfileImageBytes(user *User) ([]byte, error) {
-	// check if exist in cache
-	if data, found := u.retrieveImageFromCache(user.Login); found {
-		log.Debugf("image [%s] found in cache", user.Login)
-		return data, nil
+	if len(batch.logins) == maxUsersPerRequest {
+		u.pending = nil // full, following lookups go to a new batch
+		go u.request(batch)
 	}
+	u.mutex.Unlock()
 
-	// download it
-	resp, err := u.c.httpClient.Get(user.ProfileImageURL)
-	if err != nil {
-		return nil, fmt.Errorf("unable to read profile image URL: %w", err)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-batch.done:
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 response code")
+	if batch.err != nil {
+		return nil, batch.err
 	}
 
-	// duplicate response body
-	var contentTypeBuffer bytes.Buffer
-	tee := io.TeeReader(resp.Body, &contentTypeBuffer)
-	defer resp.Body.Close() // we are done with body
+	user, ok := batch.users[login]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, login)
+	}
 
-	// read it, it contains the image
-	imageBytes, _ := io.ReadAll(tee)
-	var imageBuffer = bytes.NewBuffer(imageBytes)
+	return user, nil
+}
 
-	// detect image type
-	// https://stackoverflow.com/a/38175140
-	buff := make([]byte, 512)
-	if _, err := contentTypeBuffer.Read(buff); err != nil {
-		return nil, fmt.Errorf("unable determine image type: %w", err)
+// flush requests given batch at the end of its window, unless already requested for being full
+func (u *usersClient) flush(batch *userBatch) {
+	u.mutex.Lock()
+	if u.pending != batch {
+		u.mutex.Unlock()
+		return
 	}
-	imageContentType := http.DetectContentType(buff)
 
-	// load image
-	var img image.Image
-	switch imageContentType {
-	case "image/png":
-		img, err = png.Decode(imageBuffer)
-	case "image/jpeg":
-		img, err = jpeg.Decode(imageBuffer)
-	default:
-		return nil, fmt.Errorf("unexpected image content-type: %s", imageContentType)
-	}
+	u.pending = nil
+	u.mutex.Unlock()
+	u.request(batch)
+}
+
+// request gets the users of given batch. It does not depend on the context of any lookup, as others wait for it.
+func (u *usersClient) request(batch *userBatch) {
+	defer close(batch.done)
 
+	users, err := u.Get(context.Background(), batch.logins...)
 	if err != nil {
-		return nil, fmt.Errorf("unable to decode image: %w", err)
+		batch.err = err
+		return
 	}
 
-	// resize it
-	img = resize.Resize(32, 32, img, resize.NearestNeighbor)
-
-	imageBuffer.Reset()
-	switch runtime.GOOS {
-	case "windows":
-		// Windows need .ico image format
-		err = ico.Encode(imageBuffer, img)
-	default:
-		// Default re-encode to png
-		err = png.Encode(imageBuffer, img)
+	batch.users = make(map[string]*User, len(users))
+	for _, user := range users {
+		batch.users[user.Login] = user
 	}
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("unable to encode image: %w", err)
+func (u *usersClient) ProfileImageBytes(ctx context.Context, user *User) ([]byte, error) {
+	name := imageKey("avatar", user.ProfileImageURL)
+	if user.ProfileImageURL == "" {
+		name = imageKey("avatar-placeholder", user.ID)
 	}
 
-	// store image in cache
-	data := imageBuffer.Bytes()
-	log.Debugf("storing image [%s] in cache", user.Login)
-	if err := u.storeImageInCache(data, user.Login); err != nil {
-		log.Errorf("unable to store image [%s] in cache: %v", user.Login, err)
+	if data, found := u.c.retrieveImageFromCache(name); found {
+		log.Debugf("image [%s] found in cache", name)
+		return data, nil
 	}
 
-	return data, nil
-}
+	img := placeholderImage(user)
+	if user.ProfileImageURL != "" {
+		downloaded, err := u.c.downloadImage(ctx, user.ProfileImageURL)
+		switch {
+		case errors.Is(err, errUnsupportedImage):
+			log.Warningf("using a placeholder as profile image of %s: %s", user.Login, err)
+		case err != nil:
+			return nil, err
+		default:
+			img = downloaded
+		}
+	}
 
-// storeImageInCache write given bytes to file
-func (u *usersClient) storeImageInCache(image []byte, name string) error {
-	return u.c.cache.Write(name, image)
+	return u.c.storeIcon(img, name, profileImageSize, profileImageSize)
 }
 
-// retrieveImageFromCache return given file if exist
-func (u *usersClient) retrieveImageFromCache(name string) ([]byte, bool) {
-	if !u.c.cache.Has(name) {
-		return nil, false
+func (u *usersClient) ProfileImagePath(ctx context.Context, user *User) (string, error) {
+	if user.ProfileImageURL == "" {
+		return "", fmt.Errorf("no profile image for %s", user.Login)
 	}
 
-	data, err := u.c.cache.Read(name)
-	if err != nil {
-		log.Warningf("error while loading file: %v", err)
-		return nil, false
-	}
+	return u.c.imageFile(ctx, user.ProfileImageURL, imageKey("avatar-original", user.ProfileImageURL), imageCacheTTL)
+}
 
-	return data, true
+// forget removes the users already read, they are requested again on next lookup
+func (u *usersClient) forget() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.users = make(map[string]*cachedUser)
 }
 
 func (u *usersClient) Me() *User {
+	u.c.session.RLock()
+	defer u.c.session.RUnlock()
 	return u.c.me
 }
@@ -0,0 +1,85 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_usersClient_Resolve(t *testing.T) {
+	srv := newTestServer(t)
+	var logins []string
+	for i := 0; i < 150; i++ {
+		login := "user" + strconv.Itoa(i)
+		srv.AddUser(&twitchtest.User{ID: strconv.Itoa(1000 + i), Login: login})
+		logins = append(logins, login)
+	}
+
+	tests := []struct {
+		name     string
+		logins   []string
+		requests int // number of requests to /users
+		wantErr  error
+	}{
+		{
+			name:     "Expected concurrent lookups requested by batches of 100",
+			logins:   logins,
+			requests: 2,
+		},
+		{
+			name:     "Expected duplicates requested once",
+			logins:   []string{"user1", "USER1", "user2"},
+			requests: 1,
+		},
+		{
+			name:     "Expected unknown user",
+			logins:   []string{"unknown"},
+			requests: 1,
+			wantErr:  ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, srv)
+			before := srv.Requests("/users")
+
+			errs := make([]error, len(tt.logins))
+			var wg sync.WaitGroup
+			for i, login := range tt.logins {
+				wg.Add(1)
+				go func(i int, login string) {
+					defer wg.Done()
+					user, err := c.Users.Resolve(context.Background(), login)
+					if err == nil && user.Login != strings.ToLower(login) {
+						err = errors.New("unexpected user " + user.Login)
+					}
+					errs[i] = err
+				}(i, login)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if got := srv.Requests("/users") - before; got != tt.requests {
+				t.Errorf("Resolve() requests = %v, want %v", got, tt.requests)
+			}
+
+			// users are remembered
+			before = srv.Requests("/users")
+			if tt.wantErr == nil {
+				_, _ = c.Users.Resolve(context.Background(), tt.logins[0])
+				if got := srv.Requests("/users") - before; got != 0 {
+					t.Errorf("Resolve() requests = %v, want %v", got, 0)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+const videosURI = "/videos"
+
+var _ VideosI = (*videosClient)(nil)
+
+// VideoType filters videos by kind
+type VideoType string
+
+const (
+	VideoAll       VideoType = "all"
+	VideoArchive   VideoType = "archive"   // past broadcast
+	VideoHighlight VideoType = "highlight" // part of a past broadcast
+	VideoUpload    VideoType = "upload"
+)
+
+// Video describes a Twitch video
+type Video struct {
+	CreatedAt    time.Time `json:"created_at"`
+	Description  string    `json:"description"`
+	Duration     string    `json:"duration"` // e.g. 3h8m33s
+	ID           string    `json:"id"`
+	Language     string    `json:"language"`
+	PublishedAt  time.Time `json:"published_at"`
+	StreamID     string    `json:"stream_id"` // set on archives only
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Title        string    `json:"title"`
+	Type         VideoType `json:"type"`
+	URL          string    `json:"url"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	ViewCount    int       `json:"view_count"`
+	Viewable     string    `json:"viewable"`
+}
+
+// Length returns the parsed Duration, zero if it cannot be parsed
+func (v *Video) Length() time.Duration {
+	d, _ := time.ParseDuration(v.Duration)
+	return d
+}
+
+// EndedAt returns when the recording of this video ended.
+// For an archive, this is when the broadcast went offline.
+func (v *Video) EndedAt() time.Time {
+	return v.CreatedAt.Add(v.Length())
+}
+
+type VideosI interface {
+	// GetByUser returns at most max videos of given type published by given user, most recent first.
+	// https://dev.twitch.tv/docs/api/reference/#get-videos
+	GetByUser(ctx context.Context, userID string, videoType VideoType, max int) ([]*Video, error)
+}
+
+type videosClient struct {
+	c *Client
+}
+
+func (v *videosClient) GetByUser(ctx context.Context, userID string, videoType VideoType, max int) ([]*Video, error) {
+	q := make(url.Values)
+	q.Set("user_id", userID)
+	q.Set("sort", "time")
+	if videoType != "" {
+		q.Set("type", string(videoType))
+	}
+
+	return list(ctx, v.c, v.c.baseURL+videosURI, q, max, func(video *Video) string {
+		return video.ID
+	})
+}
@@ -0,0 +1,100 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_videosClient_GetByUser(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddVideo(
+		&twitchtest.Video{ID: "v1", UserID: "2", Type: "archive"},
+		&twitchtest.Video{ID: "v2", UserID: "2", Type: "highlight"},
+		&twitchtest.Video{ID: "v3", UserID: "2", Type: "archive"},
+		&twitchtest.Video{ID: "v4", UserID: "3", Type: "archive"},
+	)
+	c := newTestClient(t, srv)
+
+	type args struct {
+		userID    string
+		videoType VideoType
+		max       int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string // video IDs
+		wantErr bool
+	}{
+		{
+			name: "Expected every video of user, most recent first",
+			args: args{userID: "2", videoType: VideoAll, max: 10},
+			want: []string{"v3", "v2", "v1"},
+		},
+		{
+			name: "Expected latest archive",
+			args: args{userID: "2", videoType: VideoArchive, max: 1},
+			want: []string{"v3"},
+		},
+		{
+			name: "Expected no video",
+			args: args{userID: "4", videoType: VideoArchive, max: 1},
+			want: []string{},
+		},
+		{
+			name:    "Expected error without user",
+			args:    args{userID: "", max: 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &videosClient{c: c}
+			got, err := v.GetByUser(context.Background(), tt.args.userID, tt.args.videoType, tt.args.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetByUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			ids := make([]string, 0, len(got))
+			for _, video := range got {
+				ids = append(ids, video.ID)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("GetByUser() got = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestVideo_EndedAt(t *testing.T) {
+	createdAt := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		duration string
+		want     time.Time
+	}{
+		{
+			name:     "Expected end of broadcast",
+			duration: "3h8m33s",
+			want:     createdAt.Add(3*time.Hour + 8*time.Minute + 33*time.Second),
+		},
+		{
+			name:     "Expected creation time on invalid duration",
+			duration: "foo",
+			want:     createdAt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Video{CreatedAt: createdAt, Duration: tt.duration}
+			if got := v.EndedAt(); !got.Equal(tt.want) {
+				t.Errorf("EndedAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
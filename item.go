@@ -3,11 +3,15 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 
 	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/pkg/notifier"
+	"github.com/SkYNewZ/twitch-clip/pkg/player"
 
 	"github.com/getlantern/systray"
 	log "github.com/sirupsen/logrus"
@@ -18,14 +22,21 @@ type Item struct {
 	Application *Application
 	Item        *systray.MenuItem
 	Visible     bool
-	UserLogin   string // streamer user UserLogin (e.g. locklear)
-	Username    string // streamer displayed username (e.g. Locklear)
-	Game        string // game name on stream (e.g. Just Chatting)
+	UserID      string         // streamer user ID
+	UserLogin   string         // streamer user UserLogin (e.g. locklear)
+	Username    string         // streamer displayed username (e.g. Locklear)
+	Game        string         // game name on stream (e.g. Just Chatting)
+	stream      *twitch.Stream // last known stream, notified when the Item is shown again
+	category    *categoryMenu  // submenu containing this Item, nil at top level
+	live        *systray.MenuItem
+	chat        *systray.MenuItem
+	clips       *mediaMenu[*twitch.Clip]
+	videos      *mediaMenu[*twitch.Video]
 	mutex       sync.Mutex
 }
 
-// Show Item if not already Visible
-func (i *Item) Show() {
+// Show Item if not already Visible, notifying it if asked
+func (i *Item) Show(ctx context.Context, notify bool) {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 	if i.Visible {
@@ -34,11 +45,12 @@ func (i *Item) Show() {
 
 	i.Item.Show()
 	i.Visible = true
+	if !notify {
+		return
+	}
 
 	// Item becomes visible, notify it
-	if err := i.Application.Notifier.Notify(i.Username, i.Game, i.UserLogin); err != nil {
-		log.Errorf("fail to notify for [%s]: %s", i.UserLogin, err)
-	}
+	go i.Application.NotifyLive(ctx, i.stream)
 }
 
 // Hide Item if not already hidden
@@ -53,11 +65,18 @@ func (i *Item) Hide() {
 	i.Visible = false
 }
 
-// SetVisible set whether current Item should be Visible
-func (i *Item) SetVisible(visible bool) {
+// IsVisible returns whether current Item is Visible
+func (i *Item) IsVisible() bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.Visible
+}
+
+// SetVisible set whether current Item should be Visible, notifying it when shown if asked
+func (i *Item) SetVisible(ctx context.Context, visible, notify bool) {
 	switch visible {
 	case true:
-		i.Show()
+		i.Show(ctx, notify)
 	case false:
 		i.Hide()
 	}
@@ -73,8 +92,19 @@ func (i *Item) Refresh(s *twitch.Stream) {
 
 	i.Username = username
 	i.Game = s.GameName
-	i.Item.SetTitle(fmt.Sprintf("%s (%s)", i.Username, i.Game))
+	i.stream = s
+	i.Item.SetTitle(i.title())
 	i.Item.SetTooltip(s.Title)
+	i.live.SetTooltip(s.Title)
+}
+
+// title returns the displayed title, the game is already displayed by the category submenu
+func (i *Item) title() string {
+	if i.category != nil {
+		return i.Username
+	}
+
+	return fmt.Sprintf("%s (%s)", i.Username, i.Game)
 }
 
 func (i *Item) Disable() {
@@ -88,46 +118,81 @@ func (i *Item) Click(ctx context.Context) {
 		case <-ctx.Done():
 			log.Debugf("received context cancel: Click [%s]", i.UserLogin)
 			return // returning not to leak the goroutine
-		case <-i.Item.ClickedCh:
-			log.Debugf("[%s] Item is clicked", i.UserLogin)
-
-			// Get link
-			data, err := i.Application.Streamlink.Run(i.UserLogin)
-			if err != nil {
-				log.Errorln(err)
-				continue // do not stop this routine in case of error
-			}
-
-			// Setting in clipboard
-			u := strings.TrimSpace(string(data))
-			i.Application.ClipboardListener <- u
-
-			// Open in player and capture command output
-			var out bytes.Buffer
-			log.Debugf("openning with iina for [%s]", i.UserLogin)
-			if err := i.Application.Player.Run(u, i.UserLogin, &out); err != nil {
-				log.Errorf("[%s] cannot run command, received output: %s", i.Application.Player.Name(), out.String())
-				continue // do not stop this routine in case of error
-			}
+		case <-i.Item.ClickedCh: // platforms not opening submenus on click, notification callbacks
+		case <-i.live.ClickedCh:
+		case <-i.chat.ClickedCh:
+			i.OpenChat()
+			continue
+		}
+
+		log.Debugf("[%s] Item is clicked", i.UserLogin)
+		if i.Application.config.OpenChat {
+			i.OpenChat() // the player blocks until closed
+		}
+
+		if err := i.Application.Play("https://www.twitch.tv/"+i.UserLogin, i.UserLogin); err != nil {
+			log.Errorln(err)
 		}
 	}
 }
 
-// SetIcon pull avatar and set to given menu Item
-func (i *Item) SetIcon() {
-	users, err := i.Application.Twitch.Users.Get(i.UserLogin)
+// OpenChat opens the chat of the stream in its own window
+func (i *Item) OpenChat() {
+	if err := i.Application.chatView.Open(i.UserLogin); err != nil {
+		log.Errorf("unable to open chat of [%s]: %s", i.UserLogin, err)
+	}
+}
+
+// Play gets the stream URL of given Twitch page through streamlink, sets it to clipboard and opens it in the player
+func (a *Application) Play(page, title string) error {
+	return a.play(page, func(p player.Player, u string, output io.Writer) error {
+		return p.Run(u, title, output)
+	})
+}
+
+// play gets the stream URL of given Twitch page through streamlink, sets it to clipboard and opens it with run
+func (a *Application) play(page string, run func(p player.Player, u string, output io.Writer) error) error {
+	s, p, err := a.media()
 	if err != nil {
-		log.Errorf("unable to refresh Twitch user info for %s: %s", i.UserLogin, err)
-		return
+		return err
+	}
+
+	// Get link
+	data, err := s.RunURL(page)
+	if err != nil {
+		return err
 	}
 
-	if len(users) == 0 {
+	// Setting in clipboard
+	u := strings.TrimSpace(string(data))
+	a.ClipboardListener <- u
+
+	// Open in player and capture command output
+	var out bytes.Buffer
+	log.Debugf("opening [%s] with %s", page, p.Name())
+	if err := run(p, u, &out); err != nil {
+		return fmt.Errorf("[%s] cannot run command, received output: %s", p.Name(), out.String())
+	}
+
+	return nil
+}
+
+// SetIcon pull avatar and set to given menu Item
+func (i *Item) SetIcon(ctx context.Context) {
+	// lookups of streams added together are sent at once
+	user, err := i.Application.client().Users.Resolve(ctx, i.UserLogin)
+	if errors.Is(err, twitch.ErrUserNotFound) {
 		log.Warningf("no image found for %s", i.UserLogin)
 		return
 	}
 
+	if err != nil {
+		log.Errorf("unable to refresh Twitch user info for %s: %s", i.UserLogin, err)
+		return
+	}
+
 	// get user icon
-	img, err := i.Application.Twitch.Users.ProfileImageBytes(users[0])
+	img, err := i.Application.client().Users.ProfileImageBytes(ctx, user)
 	if err != nil {
 		log.Errorln(err)
 		return
@@ -137,6 +202,46 @@ func (i *Item) SetIcon() {
 	i.Item.SetIcon(img)
 }
 
+// NotifyLive notifies given stream going live, with the streamer avatar and a preview of the stream when available.
+// The preview is only downloaded when the notifier displays it.
+// Streams matching the Mute filter are not notified.
+func (a *Application) NotifyLive(ctx context.Context, s *twitch.Stream) {
+	if a.muted(s) {
+		log.Debugf("notifications of [%s] are muted", s.UserLogin)
+		return
+	}
+
+	stream := &notifier.Stream{
+		ID:       s.UserLogin,
+		Username: s.UserName,
+		Game:     s.GameName,
+		Title:    s.Title,
+	}
+
+	// sometimes the Twitch API does not send the username at first call, use the user UserLogin instead
+	if stream.Username == "" {
+		stream.Username = s.UserLogin
+	}
+
+	client := a.client()
+	if user, err := client.Users.Resolve(ctx, s.UserLogin); err != nil {
+		log.Warningf("notifying [%s] without avatar: %s", s.UserLogin, err)
+	} else if stream.Avatar, err = client.Users.ProfileImagePath(ctx, user); err != nil {
+		log.Warningf("notifying [%s] without avatar: %s", s.UserLogin, err)
+	}
+
+	if a.Notifier.Previews() {
+		var err error
+		if stream.Thumbnail, err = client.Streams.ThumbnailPath(ctx, s); err != nil {
+			log.Warningf("notifying [%s] without preview: %s", s.UserLogin, err)
+		}
+	}
+
+	if err := a.Notifier.Notify(stream); err != nil {
+		log.Errorf("fail to notify for [%s]: %s", s.UserLogin, err)
+	}
+}
+
 // ShouldNotify send true if current item is configured to send notifications
 func (i *Item) ShouldNotify() bool {
 	for _, user := range i.Application.config.Notifications {
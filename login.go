@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	"github.com/pkg/browser"
+	log "github.com/sirupsen/logrus"
+)
+
+// deviceCodePrompt displays the Twitch Device Code flow code in the tray and on the terminal.
+// It is reused on each login.
+type deviceCodePrompt struct {
+	mutex sync.Mutex
+	item  *systray.MenuItem
+	url   string // verification URL of the current code
+}
+
+func newDeviceCodePrompt() *deviceCodePrompt {
+	return new(deviceCodePrompt)
+}
+
+// Display shows given code, replacing the previous one if any. A nil code hides the prompt.
+func (p *deviceCodePrompt) Display(code *twitch.DeviceCode) {
+	if code == nil {
+		p.Close()
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fmt.Printf("To log in to Twitch, visit %s and enter code %s\n", code.VerificationURI, code.UserCode)
+
+	p.url = code.VerificationURI
+	title := fmt.Sprintf("Log in: enter code %s on Twitch", code.UserCode)
+	if p.item != nil {
+		p.item.SetTitle(title)
+		p.item.Show()
+		return
+	}
+
+	p.item = systray.AddMenuItem(title, "Open the Twitch activation page")
+	go p.click(p.item)
+}
+
+// click opens the verification URL each time the prompt is clicked
+func (p *deviceCodePrompt) click(item *systray.MenuItem) {
+	for range item.ClickedCh {
+		p.mutex.Lock()
+		u := p.url
+		p.mutex.Unlock()
+
+		if err := browser.OpenURL(u); err != nil {
+			log.Errorf("unable to open twitch activation page: %s", err)
+		}
+	}
+}
+
+// Close hides the prompt once logged in
+func (p *deviceCodePrompt) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.item != nil {
+		p.item.Hide()
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/getlantern/systray"
+)
+
+// mediaMenu lists clickable media such as clips, past broadcasts or scheduled streams
+type mediaMenu[T any] struct {
+	menu     *systray.MenuItem
+	empty    *systray.MenuItem
+	describe func(T) (title, tooltip string)
+
+	mutex sync.Mutex
+	slots []*mediaSlot[T] // reused, menu items cannot be removed
+}
+
+// mediaSlot is a menu item of a media
+type mediaSlot[T any] struct {
+	item  *systray.MenuItem
+	media T
+	set   bool // whether media is displayed, guarded by mediaMenu mutex
+}
+
+// newMediaMenu lists media in menu, displaying empty while there is none
+func newMediaMenu[T any](menu *systray.MenuItem, empty string, describe func(T) (string, string)) *mediaMenu[T] {
+	m := &mediaMenu[T]{
+		menu:     menu,
+		empty:    menu.AddSubMenuItem(empty, empty),
+		describe: describe,
+	}
+
+	m.empty.Disable()
+	return m
+}
+
+// Set displays given media in order, play is called with the clicked one
+func (m *mediaMenu[T]) Set(media []T, play func(T)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for len(m.slots) < len(media) {
+		slot := &mediaSlot[T]{item: m.menu.AddSubMenuItem("", "")}
+		m.slots = append(m.slots, slot)
+		go m.click(slot, play)
+	}
+
+	for i, slot := range m.slots {
+		if i >= len(media) {
+			var zero T
+			slot.media, slot.set = zero, false
+			slot.item.Hide()
+			continue
+		}
+
+		slot.media, slot.set = media[i], true
+		title, tooltip := m.describe(media[i])
+		slot.item.SetTitle(title)
+		slot.item.SetTooltip(tooltip)
+		slot.item.Show()
+	}
+
+	if len(media) == 0 {
+		m.empty.Show()
+	} else {
+		m.empty.Hide()
+	}
+}
+
+func (m *mediaMenu[T]) click(slot *mediaSlot[T], play func(T)) {
+	for range slot.item.ClickedCh {
+		m.mutex.Lock()
+		media, set := slot.media, slot.set
+		m.mutex.Unlock()
+
+		if set {
+			play(media)
+		}
+	}
+}
+
+// Refresh updates the title and tooltip of the displayed media
+func (m *mediaMenu[T]) Refresh() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, slot := range m.slots {
+		if slot.set {
+			title, tooltip := m.describe(slot.media)
+			slot.item.SetTitle(title)
+			slot.item.SetTooltip(tooltip)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	"github.com/pkg/browser"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	offlineRefreshInterval = time.Minute * 15 // followed channels and their last broadcast refresh interval
+	maxOfflineChannels     = 100              // most recently followed channels displayed in the Offline submenu
+	lastBroadcastTTL       = time.Hour * 6    // the last broadcast of offline channels is read from Twitch again after this delay
+)
+
+// offlineChannel is a followed channel with its last broadcast
+type offlineChannel struct {
+	channel  *twitch.FollowedChannel
+	lastLive time.Time       // zero if unknown
+	vodURL   string          // latest past broadcast, empty if none
+	videos   []*twitch.Video // latest past broadcasts
+	avatar   []byte
+}
+
+// lastBroadcast is the latest past broadcasts of a channel, as read from Twitch at readAt
+type lastBroadcast struct {
+	lastLive time.Time // zero if unknown
+	vodURL   string    // empty if none
+	videos   []*twitch.Video
+	readAt   time.Time
+}
+
+// offlineMenu lists followed channels which are not live
+type offlineMenu struct {
+	mutex    sync.Mutex
+	menu     *systray.MenuItem
+	empty    *systray.MenuItem
+	items    map[string]*offlineItem // by broadcaster ID
+	followed map[string]bool         // broadcaster IDs followed by the current account
+}
+
+// offlineItem opens the channel page or its latest past broadcast, and lists its latest videos
+type offlineItem struct {
+	item   *systray.MenuItem
+	open   *systray.MenuItem
+	videos *mediaMenu[*twitch.Video]
+	url    string // guarded by offlineMenu mutex
+}
+
+func newOfflineMenu() *offlineMenu {
+	menu := systray.AddMenuItem("Offline", "Followed channels not live")
+	m := &offlineMenu{
+		menu:     menu,
+		empty:    menu.AddSubMenuItem("No offline channel", "No offline channel"),
+		items:    make(map[string]*offlineItem),
+		followed: make(map[string]bool),
+	}
+
+	m.empty.Disable()
+	return m
+}
+
+// Set displays given channels, hiding the live ones.
+// Nothing is changed once ctx is done, a newer session may own the menu.
+func (m *offlineMenu) Set(ctx context.Context, a *Application, channels []*offlineChannel, live []*twitch.Stream) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.followed = make(map[string]bool, len(channels))
+	for _, c := range channels {
+		m.followed[c.channel.BroadcasterID] = true
+		title := fmt.Sprintf("%s (%s)", c.channel.BroadcasterName, lastLive(c.lastLive, time.Now()))
+		url := c.vodURL
+		tooltip := "Open latest past broadcast"
+		if url == "" {
+			url = "https://www.twitch.tv/" + c.channel.BroadcasterLogin
+			tooltip = "Open channel"
+		}
+
+		item, ok := m.items[c.channel.BroadcasterID]
+		if ok {
+			item.item.SetTitle(title)
+			item.item.SetTooltip(tooltip)
+			item.open.SetTitle(tooltip)
+			item.url = url
+		} else {
+			item = &offlineItem{item: m.menu.AddSubMenuItem(title, tooltip), url: url}
+			item.open = item.item.AddSubMenuItem(tooltip, tooltip)
+			item.videos = a.newVideosMenu(item.item)
+			if len(c.avatar) > 0 {
+				item.item.SetIcon(c.avatar)
+			}
+
+			m.items[c.channel.BroadcasterID] = item
+			go m.click(item)
+		}
+
+		item.videos.Set(c.videos, a.videoPlayer(item.videos))
+	}
+
+	m.setLive(live)
+}
+
+// SetLive hides the channels of given live streams
+func (m *offlineMenu) SetLive(ctx context.Context, live []*twitch.Stream) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.setLive(live)
+}
+
+// Clear hides every channel, the caller must make sure no session routine runs anymore
+func (m *offlineMenu) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.followed = make(map[string]bool)
+	m.setLive(nil)
+}
+
+// setLive shows followed channels not in live. The caller must hold the mutex.
+func (m *offlineMenu) setLive(live []*twitch.Stream) {
+	isLive := make(map[string]bool, len(live))
+	for _, s := range live {
+		isLive[s.UserID] = true
+	}
+
+	visible := 0
+	for id, item := range m.items {
+		if m.followed[id] && !isLive[id] {
+			item.item.Show()
+			visible++
+		} else {
+			item.item.Hide()
+		}
+	}
+
+	if visible == 0 {
+		m.empty.Show()
+	} else {
+		m.empty.Hide()
+	}
+}
+
+func (m *offlineMenu) click(item *offlineItem) {
+	for {
+		select {
+		case <-item.item.ClickedCh: // platforms not opening submenus on click
+		case <-item.open.ClickedCh:
+		}
+
+		m.mutex.Lock()
+		url := item.url
+		m.mutex.Unlock()
+
+		if err := browser.OpenURL(url); err != nil {
+			log.Errorf("unable to open %s: %s", url, err)
+		}
+	}
+}
+
+// lastLive describes how long ago a channel was live
+func lastLive(t, now time.Time) string {
+	if t.IsZero() {
+		return "offline"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Hour:
+		return "live a moment ago"
+	case d < time.Hour*24:
+		return plural(int(d/time.Hour), "hour")
+	case d < time.Hour*24*30:
+		return plural(int(d/(time.Hour*24)), "day")
+	case d < time.Hour*24*365:
+		return plural(int(d/(time.Hour*24*30)), "month")
+	default:
+		return plural(int(d/(time.Hour*24*365)), "year")
+	}
+}
+
+// plural returns "live n unit(s) ago"
+func plural(n int, unit string) string {
+	if n > 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("live %d %s ago", n, unit)
+}
+
+// RefreshOfflineChannels displays followed channels which are not live in menu.
+// Channels and their last broadcast are listed every offlineRefreshInterval, live ones are hidden every pollingInterval.
+func (a *Application) RefreshOfflineChannels(ctx context.Context, menu *offlineMenu) {
+	list := time.NewTimer(0)
+	defer list.Stop()
+
+	live := time.NewTicker(pollingInterval)
+	defer live.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugln("received context cancel: RefreshOfflineChannels")
+			return // returning not to leak the goroutine
+		case <-list.C:
+			channels, err := a.OfflineChannels(ctx)
+			if err != nil {
+				log.Errorf("unable to list followed channels: %s", err)
+			} else {
+				menu.Set(ctx, a, channels, a.ActiveStreams())
+			}
+
+			list.Reset(offlineRefreshInterval)
+		case <-live.C:
+			menu.SetLive(ctx, a.ActiveStreams())
+		}
+	}
+}
+
+// OfflineChannels returns the most recently followed channels with their avatar and latest past broadcasts,
+// most recently live first
+func (a *Application) OfflineChannels(ctx context.Context) ([]*offlineChannel, error) {
+	client := a.client()
+	followed, err := client.Channels.GetFollowed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(followed) > maxOfflineChannels {
+		followed = followed[:maxOfflineChannels]
+	}
+
+	// channels live now have a newer broadcast once offline
+	live := make(map[string]bool)
+	for _, s := range a.ActiveStreams() {
+		live[s.UserID] = true
+	}
+
+	channels := make([]*offlineChannel, 0, len(followed))
+	logins := make([]string, 0, len(followed))
+	for _, f := range followed {
+		c := &offlineChannel{channel: f}
+		channels = append(channels, c)
+		logins = append(logins, f.BroadcasterLogin)
+
+		b, err := a.lastBroadcast(ctx, client, f, live[f.BroadcasterID])
+		if err != nil {
+			log.Warningf("unable to find last broadcast of %s: %s", f.BroadcasterLogin, err)
+			continue
+		}
+
+		c.lastLive, c.vodURL, c.videos = b.lastLive, b.vodURL, b.videos
+	}
+
+	a.setAvatars(ctx, client, channels, logins)
+	sort.SliceStable(channels, func(i, j int) bool {
+		return channels[i].lastLive.After(channels[j].lastLive)
+	})
+
+	return channels, nil
+}
+
+// lastBroadcast returns the last broadcast of given channel, read from Twitch again once older than lastBroadcastTTL
+// or when stale is set
+func (a *Application) lastBroadcast(ctx context.Context, client *twitch.Client, channel *twitch.FollowedChannel, stale bool) (*lastBroadcast, error) {
+	a.broadcastsMutex.Lock()
+	cached, ok := a.broadcasts[channel.BroadcasterID]
+	a.broadcastsMutex.Unlock()
+	if ok && !stale && time.Since(cached.readAt) < lastBroadcastTTL {
+		return cached, nil
+	}
+
+	// Past broadcasts are only kept for a while, if enabled at all
+	videos, err := client.Videos.GetByUser(ctx, channel.BroadcasterID, twitch.VideoArchive, maxVideos)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &lastBroadcast{videos: videos, readAt: time.Now()}
+	if len(videos) > 0 {
+		b.lastLive = videos[0].EndedAt()
+		b.vodURL = videos[0].URL
+	}
+
+	a.broadcastsMutex.Lock()
+	a.broadcasts[channel.BroadcasterID] = b
+	a.broadcastsMutex.Unlock()
+	return b, nil
+}
+
+// setAvatars loads the avatar of each channel, by batches of 100 users
+func (a *Application) setAvatars(ctx context.Context, client *twitch.Client, channels []*offlineChannel, logins []string) {
+	users := make(map[string]*twitch.User, len(logins))
+	for i := 0; i < len(logins); i += 100 {
+		end := i + 100
+		if end > len(logins) {
+			end = len(logins)
+		}
+
+		batch, err := client.Users.Get(ctx, logins[i:end]...)
+		if err != nil {
+			log.Errorf("unable to get followed channels users: %s", err)
+			return
+		}
+
+		for _, u := range batch {
+			users[u.ID] = u
+		}
+	}
+
+	for _, c := range channels {
+		user, ok := users[c.channel.BroadcasterID]
+		if !ok {
+			continue
+		}
+
+		img, err := client.Users.ProfileImageBytes(ctx, user)
+		if err != nil {
+			log.Warningf("unable to get avatar of %s: %s", user.Login, err)
+			continue
+		}
+
+		c.avatar = img
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+)
+
+func Test_lastLive(t *testing.T) {
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "Expected unknown", t: time.Time{}, want: "offline"},
+		{name: "Expected a moment ago", t: now.Add(-time.Minute * 10), want: "live a moment ago"},
+		{name: "Expected one hour", t: now.Add(-time.Minute * 90), want: "live 1 hour ago"},
+		{name: "Expected days", t: now.Add(-time.Hour * 72), want: "live 3 days ago"},
+		{name: "Expected months", t: now.Add(-time.Hour * 24 * 65), want: "live 2 months ago"},
+		{name: "Expected years", t: now.Add(-time.Hour * 24 * 800), want: "live 2 years ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastLive(tt.t, now); got != tt.want {
+				t.Errorf("lastLive() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplication_OfflineChannels(t *testing.T) {
+	srv := twitchtest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser(
+		&twitchtest.User{ID: "1", Login: "foo"},
+		&twitchtest.User{ID: "2", Login: "bar", DisplayName: "Bar"},
+		&twitchtest.User{ID: "3", Login: "baz", DisplayName: "Baz"},
+		&twitchtest.User{ID: "4", Login: "qux", DisplayName: "Qux"},
+	)
+	srv.Follow("2", "3", "4")
+
+	createdAt := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	srv.AddVideo(
+		&twitchtest.Video{ID: "v1", UserID: "3", Type: "archive", CreatedAt: createdAt, Duration: "1h", URL: "https://www.twitch.tv/videos/v1"},
+		&twitchtest.Video{ID: "v2", UserID: "2", Type: "archive", CreatedAt: createdAt.Add(time.Hour * 24), Duration: "2h", URL: "https://www.twitch.tv/videos/v2"},
+		&twitchtest.Video{ID: "v3", UserID: "2", Type: "upload", CreatedAt: createdAt.Add(time.Hour * 48), Duration: "1m"},
+	)
+
+	a := newTestApplication(t, srv)
+	got, err := a.OfflineChannels(context.Background())
+	if err != nil {
+		t.Fatalf("OfflineChannels() error = %v", err)
+	}
+
+	type channel struct {
+		login    string
+		lastLive time.Time
+		vodURL   string
+		videos   int
+		avatar   bool
+	}
+	want := []channel{
+		{login: "bar", lastLive: createdAt.Add(time.Hour * 26), vodURL: "https://www.twitch.tv/videos/v2", videos: 1, avatar: true},
+		{login: "baz", lastLive: createdAt.Add(time.Hour), vodURL: "https://www.twitch.tv/videos/v1", videos: 1, avatar: true},
+		{login: "qux", avatar: true},
+	}
+
+	channels := make([]channel, 0, len(got))
+	for _, c := range got {
+		channels = append(channels, channel{
+			login:    c.channel.BroadcasterLogin,
+			lastLive: c.lastLive,
+			vodURL:   c.vodURL,
+			videos:   len(c.videos),
+			avatar:   len(c.avatar) > 0,
+		})
+	}
+	if !reflect.DeepEqual(channels, want) {
+		t.Errorf("OfflineChannels() got = %v, want %v", channels, want)
+	}
+}
+
+func TestApplication_OfflineChannels_cache(t *testing.T) {
+	srv := twitchtest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser(
+		&twitchtest.User{ID: "1", Login: "foo"},
+		&twitchtest.User{ID: "2", Login: "bar"},
+		&twitchtest.User{ID: "3", Login: "baz"},
+	)
+	srv.Follow("2", "3")
+
+	a := newTestApplication(t, srv)
+	if _, err := a.OfflineChannels(context.Background()); err != nil {
+		t.Fatalf("OfflineChannels() error = %v", err)
+	}
+	if got := srv.Requests("/videos"); got != 2 {
+		t.Fatalf("OfflineChannels() requested videos %d times, want 2", got)
+	}
+
+	// last broadcasts are cached
+	if _, err := a.OfflineChannels(context.Background()); err != nil {
+		t.Fatalf("OfflineChannels() error = %v", err)
+	}
+	if got := srv.Requests("/videos"); got != 2 {
+		t.Errorf("OfflineChannels() requested videos %d times, want 2", got)
+	}
+
+	// live channels are read again, they have a newer broadcast once offline
+	a.activeStreams = []*twitch.Stream{{ID: "s2", UserID: "2", UserLogin: "bar"}}
+	if _, err := a.OfflineChannels(context.Background()); err != nil {
+		t.Fatalf("OfflineChannels() error = %v", err)
+	}
+	if got := srv.Requests("/videos"); got != 3 {
+		t.Errorf("OfflineChannels() requested videos %d times, want 3", got)
+	}
+}
@@ -1,13 +1,17 @@
 package notifier
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	defaultSubtitle      = "%s start streaming %s"
+	reminderSubtitle     = "%s goes live at %s: %s"
+	alertSubtitle        = "%s in %s's chat: %s"
 	actionURI            = "/notification" // Use URI not handle notifications callback
 	serverListenAddr     = "localhost"
 	streamQueryParameter = "id"
@@ -15,13 +19,37 @@ const (
 
 // Notifier service
 type Notifier interface {
-	// Notify send a desktop notification
-	Notify(username, game, id string) error
+	// Notify send a desktop notification about a stream going live
+	Notify(stream *Stream) error
+
+	// Previews returns whether Notify displays the stream preview, not worth downloading otherwise
+	Previews() bool
+
+	// Remind send a desktop notification about a stream scheduled at start
+	Remind(username, title, id string, start time.Time) error
+
+	// Alert send a desktop notification about a chat message of channel
+	Alert(channel, username, text, id string) error
 
 	// Close stops the current notifier service (closes the underlying web server)
 	Close() error
 }
 
+// Stream describes a stream going live
+type Stream struct {
+	ID        string // sent back when the notification is clicked
+	Username  string // displayed name of the streamer
+	Game      string
+	Title     string
+	Avatar    string // path of the streamer avatar image, optional
+	Thumbnail string // path of the stream preview image, optional, only displayed when Previews is true
+}
+
+// message returns the message notifying s
+func (s *Stream) message() string {
+	return fmt.Sprintf(defaultSubtitle, s.Username, s.Game)
+}
+
 // service implements Notifier
 type service struct {
 	title string        // Notification application title
@@ -53,3 +81,13 @@ func (s *service) Close() error {
 	log.Debugln("notification service: closing web server")
 	return s.srv.Close()
 }
+
+// reminderMessage returns the message reminding given scheduled stream
+func reminderMessage(username, title string, start time.Time) string {
+	return fmt.Sprintf(reminderSubtitle, username, start.Local().Format("15:04"), title)
+}
+
+// alertMessage returns the message of given chat alert
+func alertMessage(channel, username, text string) string {
+	return fmt.Sprintf(alertSubtitle, username, channel, text)
+}
@@ -2,12 +2,31 @@ package notifier
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gen2brain/beeep"
 )
 
-func (s *service) Notify(username, game, _ string) error {
-	return beeep.Notify(s.title, fmt.Sprintf(defaultSubtitle, username, game), "")
+func (s *service) Notify(stream *Stream) error {
+	message := stream.message()
+	if stream.Title != "" {
+		message = fmt.Sprintf("%s\n%s", message, stream.Title)
+	}
+
+	return beeep.Notify(s.title, message, stream.Avatar)
+}
+
+// Previews is false, beeep only displays an icon
+func (s *service) Previews() bool {
+	return false
+}
+
+func (s *service) Remind(username, title, _ string, start time.Time) error {
+	return beeep.Notify(s.title, reminderMessage(username, title, start), "")
+}
+
+func (s *service) Alert(channel, username, text, _ string) error {
+	return beeep.Notify(s.title, alertMessage(channel, username, text), "")
 }
 
 // startServer notification callback handler is not supported on darwin as it runs a AppleScript
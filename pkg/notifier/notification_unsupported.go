@@ -5,11 +5,24 @@ package notifier
 import (
 	"errors"
 	"runtime"
+	"time"
 )
 
 var ErrUnsupported = errors.New("notification service: unsupported operation system: " + runtime.GOOS)
 
-func (s *service) Notify(username, game, id string) error {
+func (s *service) Notify(stream *Stream) error {
+	return ErrUnsupported
+}
+
+func (s *service) Previews() bool {
+	return false
+}
+
+func (s *service) Remind(username, title, id string, start time.Time) error {
+	return ErrUnsupported
+}
+
+func (s *service) Alert(channel, username, text, id string) error {
 	return ErrUnsupported
 }
 
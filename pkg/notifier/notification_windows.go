@@ -1,11 +1,17 @@
 package notifier
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"os/exec"
 	"sync"
+	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/phayes/freeport"
 
@@ -13,15 +19,111 @@ import (
 	"github.com/stuartleeks/toast"
 )
 
+const defaultIcon = "C:\\Users\\Quentin\\Sources\\alerts\\assets\\icon256.png"
+
 var once sync.Once
 
-func (s *service) Notify(username, game, id string) error {
-	log.Tracef("notification service: creating notification for [%s]", username)
+// previewTemplate is the PowerShell script displaying a toast with a hero image, which the toast library does not support.
+// Values are escaped and the XML is a single-quoted here-string, so PowerShell does not expand them.
+var previewTemplate = template.Must(template.New("preview").Funcs(template.FuncMap{"escape": template.HTMLEscapeString}).Parse(`
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.UI.Notifications.ToastNotification, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+
+$template = @'
+<toast>
+    <visual>
+        <binding template="ToastGeneric">
+            <image placement="appLogoOverride" src="{{escape .Icon}}" />
+            <image placement="hero" src="{{escape .Preview}}" />
+            {{range .Lines}}<text>{{escape .}}</text>
+            {{end}}
+        </binding>
+    </visual>
+    <audio src="{{escape .Audio}}" />
+    {{if .Action}}
+    <actions>
+        <action activationType="protocol" content="View" arguments="{{escape .Action}}" />
+    </actions>
+    {{end}}
+</toast>
+'@
+
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml($template)
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{{escape .AppID}}').Show($toast)
+`))
+
+// previewToast is the data of previewTemplate
+type previewToast struct {
+	AppID   string
+	Lines   []string // title, message and stream title
+	Icon    string
+	Preview string
+	Audio   string
+	Action  string // URL opened by the View button, none when empty
+}
+
+// Notify displays the streamer avatar as icon and the stream preview, when downloaded, as hero image.
+// The stream title is displayed below the message.
+func (s *service) Notify(stream *Stream) error {
+	log.Tracef("notification service: creating notification for [%s]", stream.Username)
+	icon := stream.Avatar
+	if icon == "" {
+		icon = defaultIcon
+	}
+
+	if stream.Thumbnail == "" {
+		message := stream.message()
+		if stream.Title != "" {
+			message = fmt.Sprintf("%s\n%s", message, stream.Title)
+		}
+
+		return s.push(message, icon, stream.ID)
+	}
+
+	t := &previewToast{
+		AppID:   s.title,
+		Lines:   []string{s.title, stream.message()},
+		Icon:    icon,
+		Preview: stream.Thumbnail,
+		Audio:   string(toast.Default),
+	}
+
+	if stream.Title != "" {
+		t.Lines = append(t.Lines, stream.Title)
+	}
+
+	if s.srv != nil {
+		t.Action = s.makeNotificationURL(stream.ID)
+	}
+
+	return pushPreview(t)
+}
+
+// Previews is true, go-live toasts display the preview as hero image
+func (s *service) Previews() bool {
+	return true
+}
+
+func (s *service) Remind(username, title, id string, start time.Time) error {
+	log.Tracef("notification service: creating reminder for [%s]", username)
+	return s.push(reminderMessage(username, title, start), defaultIcon, id)
+}
+
+func (s *service) Alert(channel, username, text, id string) error {
+	log.Tracef("notification service: creating chat alert for [%s]", channel)
+	return s.push(alertMessage(channel, username, text), defaultIcon, id)
+}
+
+// push displays message with given icon, clicking it opens the stream of given streamer
+func (s *service) push(message, icon, id string) error {
 	notification := toast.Notification{
 		AppID:    s.title,
 		Title:    s.title,
-		Message:  fmt.Sprintf(defaultSubtitle, username, game),
-		Icon:     "C:\\Users\\Quentin\\Sources\\alerts\\assets\\icon256.png",
+		Message:  message,
+		Icon:     icon,
 		Actions:  nil,
 		Audio:    toast.Default,
 		Loop:     false,
@@ -42,6 +144,33 @@ func (s *service) Notify(username, game, id string) error {
 	return notification.Push()
 }
 
+// pushPreview runs previewTemplate from a temporary script, as the toast library does
+func pushPreview(t *previewToast) error {
+	var script bytes.Buffer
+	script.Write([]byte{0xEF, 0xBB, 0xBF}) // UTF-8 BOM, PowerShell reads the script as ANSI otherwise
+	if err := previewTemplate.Execute(&script, t); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "twitchclip-toast-*.ps1")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+	_, err = f.Write(script.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("PowerShell", "-ExecutionPolicy", "Bypass", "-File", f.Name())
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd.Run()
+}
+
 func (s *service) makeNotificationURL(streamer string) string {
 	u, _ := url.Parse("http://" + s.srv.Addr + actionURI)
 	q := u.Query()
@@ -1,16 +1,25 @@
 package player
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-var _ Player = (*player)(nil)
+var (
+	_ Player  = (*player)(nil)
+	_ Seeker  = (*player)(nil)
+	_ Resumer = (*resumingPlayer)(nil)
+)
 
 // Player describes an available media player application
 // If you want to use a custom one, make sure to implement this interface
@@ -24,24 +33,72 @@ type Player interface {
 	Run(u, title string, output io.Writer) error
 }
 
+// Seeker describes a player able to start playing from a given position
+type Seeker interface {
+	// RunAt process current URL through current player, starting at given position
+	RunAt(u, title string, start time.Duration, output io.Writer) error
+}
+
+// Resumer describes a player telling where it was stopped
+type Resumer interface {
+	Seeker
+
+	// RunResume runs like RunAt until the player is closed, and returns where it was stopped.
+	// Zero is returned when the player did not save any position, e.g. once played until the end.
+	RunResume(u, title string, start time.Duration, output io.Writer) (time.Duration, error)
+}
+
+// registeredPlayer is a player of this package, looked up by DefaultPlayer
+type registeredPlayer interface {
+	Player
+	base() *player
+}
+
 type player struct {
 	name       string
 	command    []string
+	start      []string // arguments appended to command to start at $start seconds, empty if not supported
 	registry   string
 	registry32 string
 }
 
+// resumingPlayer is a player saving where it was stopped in the $state directory on quit
+type resumingPlayer struct {
+	*player
+	save []string // arguments appended to command to save the position in the $state directory
+}
+
+func (p *player) base() *player {
+	return p
+}
+
 func (p *player) Name() string {
 	return p.name
 }
 
 func (p *player) Run(u, title string, output io.Writer) error {
-	tmpCommand := make([]string, len(p.command))
+	return p.RunAt(u, title, 0, output)
+}
+
+// RunAt starts from the beginning when the player does not support a start position
+func (p *player) RunAt(u, title string, start time.Duration, output io.Writer) error {
+	return p.run(u, title, start, nil, "", output)
+}
+
+// run runs the player with extra arguments, $state being replaced by state
+func (p *player) run(u, title string, start time.Duration, extra []string, state string, output io.Writer) error {
+	tmpCommand := make([]string, len(p.command), len(p.command)+len(p.start)+len(extra))
 	copy(tmpCommand, p.command)
+	if start > 0 {
+		tmpCommand = append(tmpCommand, p.start...)
+	}
 
+	tmpCommand = append(tmpCommand, extra...)
 	for i := range tmpCommand {
 		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$url", u)
 		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$title", title)
+		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$start", strconv.Itoa(int(start/time.Second)))
+		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$state", state)
 	}
 
 	cmd := exec.Command(tmpCommand[0], tmpCommand[1:]...)
@@ -56,16 +113,63 @@ func (p *player) Run(u, title string, output io.Writer) error {
 	return cmd.Run()
 }
 
+// RunResume lets the player save its position in a temporary directory, read once it is closed
+func (p *resumingPlayer) RunResume(u, title string, start time.Duration, output io.Writer) (time.Duration, error) {
+	dir, err := os.MkdirTemp("", "twitchclip-player-")
+	if err != nil {
+		return 0, err
+	}
+
+	defer os.RemoveAll(dir)
+	if err := p.run(u, title, start, p.save, dir, output); err != nil {
+		return 0, err
+	}
+
+	return readPosition(dir)
+}
+
+// readPosition returns the position saved in dir by mpv, zero if none.
+// Each saved file holds a "start=<seconds>" line.
+func readPosition(dir string) (time.Duration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) == 0 {
+		return 0, err
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "start=")
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot read saved position: %w", err)
+		}
+
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	return 0, scanner.Err()
+}
+
 // Each player registered in the app
 // https://github.com/SoMuchForSubtlety/f1viewer/blob/master/internal/cmd/cmd.go
-var players []*player
+var players []registeredPlayer
 
 func init() {
-	players = append(players, IINA.(*player))
-	players = append(players, VLC.(*player))
-	players = append(players, MPV.(*player))
+	players = append(players, IINA.(registeredPlayer))
+	players = append(players, VLC.(registeredPlayer))
+	players = append(players, MPV.(registeredPlayer))
 	if runtime.GOOS == "darwin" {
-		players = append(players, QuickTimePlayer.(*player))
+		players = append(players, QuickTimePlayer.(registeredPlayer))
 	}
 }
 
@@ -79,6 +183,7 @@ var (
 	IINA Player = &player{
 		name:       "IINA",
 		command:    []string{"iina", "--no-stdin", "$url"},
+		start:      []string{"--mpv-start=$start"},
 		registry:   "",
 		registry32: "",
 	}
@@ -87,12 +192,17 @@ var (
 		registry:   "SOFTWARE\\VideoLAN\\VLC",
 		registry32: "SOFTWARE\\WOW6432Node\\VideoLAN\\VLC",
 		command:    []string{"vlc", "$url", "--meta-title=$title"},
+		start:      []string{"--start-time=$start"},
 	}
-	MPV Player = &player{
-		name:       "MPV",
-		command:    []string{"mpv", "$url", "--quiet", "--title=$title"},
-		registry:   "",
-		registry32: "",
+	MPV Player = &resumingPlayer{
+		player: &player{
+			name:       "MPV",
+			command:    []string{"mpv", "$url", "--quiet", "--title=$title"},
+			start:      []string{"--start=$start"},
+			registry:   "",
+			registry32: "",
+		},
+		save: []string{"--save-position-on-quit", "--watch-later-directory=$state"},
 	}
 )
 
@@ -115,8 +225,8 @@ func (p *player) checkIfExist() bool {
 func DefaultPlayer() (Player, error) {
 	// For each player, check if found in $PATH or Windows registry and use it
 	for _, player := range players {
-		if player.checkIfExist() {
-			log.Tracef("found player [%s] at [%s]", player.Name(), player.command[0])
+		if player.base().checkIfExist() {
+			log.Tracef("found player [%s] at [%s]", player.Name(), player.base().command[0])
 			return player, nil
 		}
 	}
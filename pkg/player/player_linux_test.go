@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultPlayer(t *testing.T) {
@@ -110,3 +111,97 @@ func Test_player_Run(t *testing.T) {
 		})
 	}
 }
+
+func Test_player_RunAt(t *testing.T) {
+	type fields struct {
+		command []string
+		start   []string
+	}
+
+	// Read command output
+	var buff bytes.Buffer
+
+	type args struct {
+		u     string
+		start time.Duration
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   string // read command output
+	}{
+		{
+			name:   "Expected with $start",
+			fields: fields{command: []string{"echo", "$url"}, start: []string{"--start=$start"}},
+			args:   args{u: "world", start: time.Minute + time.Second*30},
+			want:   "world --start=90",
+		},
+		{
+			name:   "From the beginning",
+			fields: fields{command: []string{"echo", "$url"}, start: []string{"--start=$start"}},
+			args:   args{u: "world", start: 0},
+			want:   "world",
+		},
+		{
+			name:   "Start not supported",
+			fields: fields{command: []string{"echo", "$url"}},
+			args:   args{u: "world", start: time.Minute},
+			want:   "world",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer buff.Reset() //empty buffer after each tests
+			p := &player{
+				name:    "Echo",
+				command: tt.fields.command,
+				start:   tt.fields.start,
+			}
+
+			if err := p.RunAt(tt.args.u, "", tt.args.start, &buff); err != nil {
+				t.Errorf("RunAt() error = %v", err)
+			}
+
+			got := strings.TrimSpace(buff.String())
+			if got != tt.want {
+				t.Errorf("RunAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resumingPlayer_RunResume(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string // run with the URL as $0 and the state directory as $1
+		want   time.Duration
+	}{
+		{
+			name:   "Expected saved position",
+			script: `printf '# %s\nstart=90.500000\n' "$0" > "$1/saved"`,
+			want:   time.Second*90 + time.Millisecond*500,
+		},
+		{
+			name:   "Expected zero when nothing saved",
+			script: "true",
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &resumingPlayer{
+				player: &player{name: "Shell", command: []string{"sh", "-c", tt.script, "$url"}},
+				save:   []string{"$state"},
+			}
+
+			got, err := p.RunResume("world", "", 0, nil)
+			if err != nil {
+				t.Fatalf("RunResume() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RunResume() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,9 @@ var (
 type Client interface {
 	// Run gets the given streamer's stream URL
 	Run(streamer string) ([]byte, error)
+
+	// RunURL gets the stream URL of given Twitch page, such as a clip or a past broadcast
+	RunURL(u string) ([]byte, error)
 }
 
 // client implements Client interface
@@ -54,12 +57,15 @@ func New(opts ...string) (Client, error) {
 }
 
 func (c *client) Run(streamer string) ([]byte, error) {
+	return c.RunURL(fmt.Sprintf("https://www.twitch.tv/%s", streamer))
+}
+
+func (c *client) RunURL(u string) ([]byte, error) {
 	// Fill arguments with URL
 	var tmpCommand = make([]string, len(c.Options))
 	copy(tmpCommand, c.Options)
 	for i := range tmpCommand {
-		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$url", fmt.Sprintf("https://www.twitch.tv/%s", streamer))
-
+		tmpCommand[i] = strings.ReplaceAll(tmpCommand[i], "$url", u)
 	}
 
 	// run cmd with a timeout of 10 seconds
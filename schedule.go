@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	"github.com/pkg/browser"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	scheduleRefreshInterval = time.Hour   // schedules are cached by the Twitch client for longer
+	reminderCheckInterval   = time.Minute // scheduled streams are checked for reminders this often
+	defaultReminderMinutes  = 10          // reminders are sent this many minutes before a scheduled stream by default
+)
+
+// scheduleMenu lists the streams scheduled for the rest of the day
+type scheduleMenu struct {
+	mutex sync.Mutex
+	media *mediaMenu[*twitch.Segment]
+}
+
+func newScheduleMenu() *scheduleMenu {
+	menu := systray.AddMenuItem("Coming up", "Streams scheduled today")
+	return &scheduleMenu{media: newMediaMenu(menu, "No scheduled stream", describeSegment)}
+}
+
+// Set displays given segments.
+// Nothing is changed once ctx is done, a newer session may own the menu.
+func (m *scheduleMenu) Set(ctx context.Context, segments []*twitch.Segment) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.media.Set(segments, openSegment)
+}
+
+// Clear hides every segment, the caller must make sure no session routine runs anymore
+func (m *scheduleMenu) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.media.Set(nil, openSegment)
+}
+
+// describeSegment returns the displayed title and tooltip of given segment
+func describeSegment(s *twitch.Segment) (string, string) {
+	title := fmt.Sprintf("%s %s", s.StartTime.Local().Format("15:04"), s.BroadcasterName)
+	if s.Category != nil {
+		title = fmt.Sprintf("%s (%s)", title, s.Category.Name)
+	}
+
+	return title, s.Title
+}
+
+// openSegment opens the channel page of given segment
+func openSegment(s *twitch.Segment) {
+	u := "https://www.twitch.tv/" + s.BroadcasterLogin
+	if err := browser.OpenURL(u); err != nil {
+		log.Errorf("unable to open %s: %s", u, err)
+	}
+}
+
+// RefreshSchedule displays the streams scheduled today in menu, refreshed every scheduleRefreshInterval,
+// and reminds the configured streamers' scheduled streams before they start.
+func (a *Application) RefreshSchedule(ctx context.Context, menu *scheduleMenu) {
+	refresh := time.NewTimer(0)
+	defer refresh.Stop()
+
+	check := time.NewTicker(reminderCheckInterval)
+	defer check.Stop()
+
+	var segments []*twitch.Segment
+	reminded := make(map[string]bool) // by segment ID and start time
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugln("received context cancel: RefreshSchedule")
+			return // returning not to leak the goroutine
+		case <-refresh.C:
+			s, err := a.ScheduledStreams(ctx)
+			if err != nil {
+				log.Errorf("unable to read schedules: %s", err)
+			} else {
+				segments = s
+			}
+
+			menu.Set(ctx, upcomingToday(segments, time.Now()))
+			refresh.Reset(scheduleRefreshInterval)
+		case <-check.C:
+			menu.Set(ctx, upcomingToday(segments, time.Now())) // hide started streams
+		}
+
+		a.Remind(segments, reminded, time.Now())
+	}
+}
+
+// ScheduledStreams returns the segments scheduled by the most recently followed channels and by the streamers to remind
+func (a *Application) ScheduledStreams(ctx context.Context) ([]*twitch.Segment, error) {
+	client := a.client()
+	followed, err := client.Channels.GetFollowed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(followed) > maxOfflineChannels {
+		followed = followed[:maxOfflineChannels]
+	}
+
+	broadcasters := make([]string, 0, len(followed)+len(a.config.Reminders))
+	seen := make(map[string]bool, len(followed))
+	for _, f := range followed {
+		broadcasters = append(broadcasters, f.BroadcasterID)
+		seen[strings.ToLower(f.BroadcasterLogin)] = true
+	}
+
+	// streamers to remind may not be followed
+	var others []string
+	for _, login := range a.config.Reminders {
+		if !seen[strings.ToLower(login)] {
+			others = append(others, login)
+		}
+	}
+
+	if len(others) > 0 {
+		users, err := client.Users.Get(ctx, others...)
+		if err != nil {
+			log.Errorf("unable to find streamers to remind: %s", err)
+		}
+
+		for _, u := range users {
+			broadcasters = append(broadcasters, u.ID)
+		}
+	}
+
+	var segments []*twitch.Segment
+	for _, id := range broadcasters {
+		s, err := client.Schedule.Get(ctx, id)
+		if err != nil {
+			log.Warningf("unable to read schedule of %s: %s", id, err)
+			continue
+		}
+
+		segments = append(segments, s...)
+	}
+
+	return segments, nil
+}
+
+// upcomingToday returns the segments not canceled starting between now and the end of the day, soonest first
+func upcomingToday(segments []*twitch.Segment, now time.Time) []*twitch.Segment {
+	year, month, day := now.Date()
+	tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+
+	upcoming := make([]*twitch.Segment, 0)
+	for _, s := range segments {
+		if !s.Canceled() && s.StartTime.After(now) && s.StartTime.Before(tomorrow) {
+			upcoming = append(upcoming, s)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].StartTime.Before(upcoming[j].StartTime)
+	})
+
+	return upcoming
+}
+
+// Remind notifies the segments of the configured streamers starting soon, once each
+func (a *Application) Remind(segments []*twitch.Segment, reminded map[string]bool, now time.Time) {
+	lead := time.Duration(a.config.ReminderMinutes) * time.Minute
+	if lead <= 0 {
+		lead = defaultReminderMinutes * time.Minute
+	}
+
+	for _, s := range segments {
+		key := s.ID + s.StartTime.String()
+		switch {
+		case reminded[key], s.Canceled(), !a.shouldRemind(s.BroadcasterLogin):
+		case now.Before(s.StartTime.Add(-lead)), !now.Before(s.StartTime):
+		default:
+			reminded[key] = true
+			if err := a.Notifier.Remind(s.BroadcasterName, s.Title, s.BroadcasterLogin, s.StartTime); err != nil {
+				log.Errorf("fail to remind scheduled stream of [%s]: %s", s.BroadcasterLogin, err)
+			}
+		}
+	}
+}
+
+// shouldRemind returns whether the scheduled streams of given streamer are reminded
+func (a *Application) shouldRemind(login string) bool {
+	for _, user := range a.config.Reminders {
+		if strings.EqualFold(user, login) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch/twitchtest"
+	"github.com/SkYNewZ/twitch-clip/pkg/notifier"
+)
+
+// fakeNotifier records notified streamers
+type fakeNotifier struct {
+	notified []string
+	streams  []*notifier.Stream
+	previews bool
+}
+
+func (n *fakeNotifier) Notify(stream *notifier.Stream) error {
+	n.notified = append(n.notified, stream.ID)
+	n.streams = append(n.streams, stream)
+	return nil
+}
+
+func (n *fakeNotifier) Previews() bool { return n.previews }
+
+func (n *fakeNotifier) Remind(_, _, id string, _ time.Time) error {
+	n.notified = append(n.notified, id)
+	return nil
+}
+
+func (n *fakeNotifier) Alert(_, _, text, id string) error {
+	n.notified = append(n.notified, id+": "+text)
+	return nil
+}
+
+func (n *fakeNotifier) Close() error { return nil }
+
+func Test_upcomingToday(t *testing.T) {
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.Local)
+	canceled := now.Add(time.Hour)
+	segments := []*twitch.Segment{
+		{ID: "later", StartTime: now.Add(time.Hour * 3)},
+		{ID: "started", StartTime: now.Add(-time.Minute)},
+		{ID: "soon", StartTime: now.Add(time.Minute * 30)},
+		{ID: "canceled", StartTime: now.Add(time.Minute * 30), CanceledUntil: &canceled},
+		{ID: "tomorrow", StartTime: now.Add(time.Hour * 4)},
+	}
+
+	got := make([]string, 0)
+	for _, s := range upcomingToday(segments, now) {
+		got = append(got, s.ID)
+	}
+	if want := []string{"soon", "later"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("upcomingToday() got = %v, want %v", got, want)
+	}
+}
+
+func TestApplication_Remind(t *testing.T) {
+	now := time.Date(2023, 5, 16, 20, 0, 0, 0, time.UTC)
+	canceled := now.Add(time.Hour)
+	segments := []*twitch.Segment{
+		{ID: "soon", BroadcasterLogin: "foo", StartTime: now.Add(time.Minute * 5)},
+		{ID: "later", BroadcasterLogin: "foo", StartTime: now.Add(time.Minute * 30)},
+		{ID: "started", BroadcasterLogin: "foo", StartTime: now.Add(-time.Minute)},
+		{ID: "canceled", BroadcasterLogin: "foo", StartTime: now.Add(time.Minute * 5), CanceledUntil: &canceled},
+		{ID: "other", BroadcasterLogin: "bar", StartTime: now.Add(time.Minute * 5)},
+	}
+
+	tests := []struct {
+		name    string
+		minutes int
+		want    []string
+	}{
+		{name: "Expected default lead", minutes: 0, want: []string{"foo"}},
+		{name: "Expected configured lead", minutes: 45, want: []string{"foo", "foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &fakeNotifier{}
+			a := &Application{
+				Notifier: n,
+				config:   &config.Config{Reminders: []string{"Foo"}, ReminderMinutes: tt.minutes},
+			}
+
+			reminded := make(map[string]bool)
+			a.Remind(segments, reminded, now)
+			a.Remind(segments, reminded, now.Add(time.Minute)) // reminded once
+			if !reflect.DeepEqual(n.notified, tt.want) {
+				t.Errorf("Remind() notified = %v, want %v", n.notified, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplication_ScheduledStreams(t *testing.T) {
+	srv := twitchtest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser(
+		&twitchtest.User{ID: "1", Login: "foo"},
+		&twitchtest.User{ID: "2", Login: "bar", DisplayName: "Bar"},
+		&twitchtest.User{ID: "3", Login: "baz", DisplayName: "Baz"},
+		&twitchtest.User{ID: "4", Login: "qux", DisplayName: "Qux"},
+	)
+	srv.Follow("2")
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	srv.AddSegment("2", &twitchtest.Segment{ID: "s2", StartTime: start})
+	srv.AddSegment("3", &twitchtest.Segment{ID: "s3", StartTime: start})
+	srv.AddSegment("4", &twitchtest.Segment{ID: "s4", StartTime: start})
+
+	a := newTestApplication(t, srv)
+	a.config.Reminders = []string{"baz"}
+
+	segments, err := a.ScheduledStreams(context.Background())
+	if err != nil {
+		t.Fatalf("ScheduledStreams() error = %v", err)
+	}
+
+	got := make([]string, 0, len(segments))
+	for _, s := range segments {
+		got = append(got, s.ID+"/"+s.BroadcasterName)
+	}
+	sort.Strings(got)
+	if want := []string{"s2/Bar", "s3/Baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ScheduledStreams() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/getlantern/systray"
+	log "github.com/sirupsen/logrus"
+)
+
+// sessionMenu displays the connected user and lets them log out, log in again or switch account
+type sessionMenu struct {
+	user        *systray.MenuItem
+	logout      *systray.MenuItem
+	login       *systray.MenuItem
+	reauthorize *systray.MenuItem // shown when a feature needs scopes not granted
+	accounts    *accountsMenu
+	offline     *offlineMenu
+	schedule    *scheduleMenu
+
+	missing []string // scopes offered by reauthorize
+}
+
+func newSessionMenu(accounts *config.Accounts) *sessionMenu {
+	m := &sessionMenu{
+		user:        systray.AddMenuItem("", "Current user"),
+		logout:      systray.AddMenuItem("Log out", "Log out from Twitch"),
+		login:       systray.AddMenuItem("Log in to Twitch…", "Log in to Twitch"),
+		reauthorize: systray.AddMenuItem("Grant Twitch permissions…", "Log in again to grant missing permissions"),
+		accounts:    newAccountsMenu(accounts),
+		offline:     newOfflineMenu(),
+		schedule:    newScheduleMenu(),
+	}
+
+	m.user.Disable()
+	m.login.Hide()
+	m.reauthorize.Hide()
+	return m
+}
+
+// MissingScopes offers to log in again to grant given scopes, along with the ones already offered
+func (m *sessionMenu) MissingScopes(scopes []string) {
+	for _, scope := range scopes {
+		if !containsFold(m.missing, scope) {
+			m.missing = append(m.missing, scope)
+		}
+	}
+
+	m.reauthorize.SetTooltip(fmt.Sprintf("Log in again to grant %s", strings.Join(m.missing, ", ")))
+	m.reauthorize.Show()
+}
+
+// LoggedIn displays given user
+func (m *sessionMenu) LoggedIn(me *twitch.User) {
+	m.user.SetTitle(fmt.Sprintf("Connected as %s", me.DisplayName))
+	m.user.Show()
+	m.logout.Show()
+	m.login.Hide()
+}
+
+// LoggingIn displays a login in progress
+func (m *sessionMenu) LoggingIn() {
+	m.user.Hide()
+	m.logout.Hide()
+	m.reauthorize.Hide()
+	m.login.SetTitle("Logging in to Twitch…")
+	m.login.Disable()
+	m.login.Show()
+}
+
+// LoggedOut offers to log in again
+func (m *sessionMenu) LoggedOut() {
+	m.user.Hide()
+	m.logout.Hide()
+	m.reauthorize.Hide()
+	m.missing = nil
+	m.login.SetTitle("Log in to Twitch…")
+	m.login.Enable()
+	m.login.Show()
+}
+
+// Clear hides the followed channels lists, the caller must make sure no session routine runs anymore
+func (m *sessionMenu) Clear() {
+	m.offline.Clear()
+	m.schedule.Clear()
+}
+
+// HandleSession runs routines requiring a logged in user and handles login, logout, account switches and invalid sessions.
+// Displayed streams are cleared while logged out.
+func (a *Application) HandleSession(ctx context.Context, out chan<- []*twitch.Stream, menu *sessionMenu) {
+	invalid := make(chan error, 1)
+	stop := a.StartSession(ctx, out, invalid, menu)
+	menu.LoggedIn(a.client().Users.Me())
+	a.rememberAccount(menu.accounts)
+
+	for {
+		select {
+		case <-ctx.Done():
+			stop()
+			log.Debugln("received context cancel: HandleSession")
+			return // returning not to leak the goroutine
+		case <-menu.logout.ClickedCh:
+			log.Infoln("logging out from Twitch")
+			stop()
+			if err := a.client().Logout(ctx); err != nil {
+				log.Errorf("unable to revoke twitch session: %s", err)
+			}
+
+			a.forgetAccount(menu.accounts)
+			a.PublishActiveStreams(ctx, out, func([]*twitch.Stream) []*twitch.Stream { return nil })
+			menu.Clear()
+			menu.LoggedOut()
+		case err := <-invalid:
+			log.Warningf("%s, logging in again", err)
+			stop()
+			a.PublishActiveStreams(ctx, out, func([]*twitch.Stream) []*twitch.Stream { return nil })
+			menu.Clear()
+			stop = a.Login(ctx, out, invalid, menu)
+		case <-menu.login.ClickedCh:
+			stop = a.Login(ctx, out, invalid, menu)
+		case scopes := <-a.missingScopes:
+			menu.MissingScopes(scopes)
+		case <-menu.reauthorize.ClickedCh:
+			log.Infof("asking for Twitch permissions: %s", strings.Join(menu.missing, " "))
+			stop()
+			stop = a.Reauthorize(ctx, out, invalid, menu)
+		case namespace := <-menu.accounts.switchTo:
+			if namespace == a.accounts.CurrentNamespace() && a.client().Users.Me() != nil {
+				menu.accounts.SetCurrent(namespace) // undo the checkbox toggle
+				continue
+			}
+
+			log.Infof("switching to Twitch account %s", namespace)
+			stop()
+			a.PublishActiveStreams(ctx, out, func([]*twitch.Stream) []*twitch.Stream { return nil })
+			menu.Clear()
+			stop = a.SwitchAccount(ctx, out, invalid, menu, namespace)
+		case <-menu.accounts.add.ClickedCh:
+			log.Infoln("adding a Twitch account")
+			stop()
+			a.PublishActiveStreams(ctx, out, func([]*twitch.Stream) []*twitch.Stream { return nil })
+			menu.Clear()
+			stop = a.SwitchAccount(ctx, out, invalid, menu, a.accounts.NewNamespace())
+		case <-menu.accounts.merge.ClickedCh:
+			a.ToggleMerge(menu.accounts)
+		}
+	}
+}
+
+// Login logs in to Twitch and starts the session routines.
+// The returned function stops them.
+func (a *Application) Login(ctx context.Context, out chan<- []*twitch.Stream, invalid chan<- error, menu *sessionMenu) context.CancelFunc {
+	menu.LoggingIn()
+	defer a.loginPrompt.Close()
+
+	if err := a.client().Login(ctx); err != nil {
+		log.Errorf("unable to log in to Twitch: %s", err)
+		menu.LoggedOut()
+		return func() {}
+	}
+
+	menu.LoggedIn(a.client().Users.Me())
+	a.rememberAccount(menu.accounts)
+	return a.StartSession(ctx, out, invalid, menu)
+}
+
+// Reauthorize logs in again granting the scopes offered by the session menu and restarts the session routines.
+// The current session is kept if the user does not approve.
+func (a *Application) Reauthorize(ctx context.Context, out chan<- []*twitch.Stream, invalid chan<- error, menu *sessionMenu) context.CancelFunc {
+	menu.LoggingIn()
+	defer a.loginPrompt.Close()
+
+	if err := a.client().Reauthorize(ctx, menu.missing...); err != nil {
+		log.Errorf("unable to grant Twitch permissions: %s", err)
+	} else {
+		menu.missing = nil
+	}
+
+	if a.client().Users.Me() == nil {
+		menu.LoggedOut()
+		return func() {}
+	}
+
+	menu.LoggedIn(a.client().Users.Me())
+	if len(menu.missing) > 0 {
+		menu.MissingScopes(nil) // offer them again
+	}
+
+	return a.StartSession(ctx, out, invalid, menu)
+}
+
+// requestScopes offers to grant the scopes missing according to err, if any
+func (a *Application) requestScopes(err error) {
+	var missing *twitch.MissingScopeError
+	if !errors.As(err, &missing) {
+		return
+	}
+
+	select {
+	case a.missingScopes <- missing.Scopes:
+	default: // already offered
+	}
+}
+
+// StartSession starts routines requiring a logged in user: streams refresh, EventSub, offline channels, schedules,
+// chat alerts and session validation.
+// The returned function stops them. An invalid session is sent to invalid.
+func (a *Application) StartSession(ctx context.Context, out chan<- []*twitch.Stream, invalid chan<- error, menu *sessionMenu) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// start routines for refreshing streams
+	go a.RefreshActiveStreams(ctx, out)
+
+	// start routine receiving followed streams changes as they happen
+	if !a.config.DisableEventSub {
+		go a.ListenEvents(ctx, out)
+	}
+
+	// list followed channels which are not live
+	go a.RefreshOfflineChannels(ctx, menu.offline)
+
+	// list scheduled streams and remind them
+	go a.RefreshSchedule(ctx, menu.schedule)
+
+	// notify chat messages matching the configured rules
+	if a.config.ChatAlerts.Enabled() {
+		go a.WatchChatAlerts(ctx)
+	}
+
+	// validate the session as required by Twitch
+	go a.client().ValidateEvery(ctx, validateInterval, func(err error) {
+		select {
+		case invalid <- err:
+		default: // already reported
+		}
+	})
+
+	return cancel
+}
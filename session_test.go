@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+)
+
+func TestApplication_requestScopes(t *testing.T) {
+	a := &Application{missingScopes: make(chan []string, 1)}
+
+	a.requestScopes(errors.New("unable to read chat"))
+	a.requestScopes(&twitch.MissingScopeError{Scopes: []string{"chat:read"}})
+	a.requestScopes(&twitch.MissingScopeError{Scopes: []string{"whispers:read"}}) // already offered, must not block
+
+	select {
+	case got := <-a.missingScopes:
+		if want := []string{"chat:read"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("requestScopes() got = %v, want %v", got, want)
+		}
+	default:
+		t.Fatalf("requestScopes() offered nothing")
+	}
+
+	select {
+	case got := <-a.missingScopes:
+		t.Errorf("requestScopes() got = %v, want a single offer", got)
+	default:
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/pkg/player"
+	"github.com/SkYNewZ/twitch-clip/pkg/streamlink"
+	"github.com/getlantern/systray"
+	"github.com/pkg/browser"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	minAttachBackoff = time.Second * 2 // first delay before retrying an unavailable subsystem
+	maxAttachBackoff = time.Minute * 5 // maximum delay between two attempts
+
+	streamlinkInstallURL = "https://streamlink.github.io/install.html"
+	playerInstallURL     = "https://www.videolan.org/vlc/"
+)
+
+// problem prevents a subsystem from working
+type problem int
+
+// Problems, the most important first
+const (
+	problemNetwork    problem = iota // Twitch cannot be reached
+	problemLogin                     // the user must log in to Twitch
+	problemStreamlink                // streamlink is not installed
+	problemPlayer                    // no media player is installed
+)
+
+// problemInfo describes how a problem is displayed and solved
+type problemInfo struct {
+	title   string
+	tooltip string
+	url     string // page opened on click, the subsystem is retried instead when empty
+	manual  bool   // only retried on click, e.g. not to open the login page again and again
+}
+
+var problems = map[problem]problemInfo{
+	problemNetwork:    {title: "Waiting for network…", tooltip: "Twitch cannot be reached, click to retry now"},
+	problemLogin:      {title: "Log in to Twitch…", tooltip: "Log in to Twitch to list followed streams", manual: true},
+	problemStreamlink: {title: "streamlink not found – open install page", tooltip: "streamlink is required to play streams", url: streamlinkInstallURL},
+	problemPlayer:     {title: "No media player found – open install page", tooltip: "VLC, MPV or IINA is required to play streams", url: playerInstallURL},
+}
+
+// statusMenu displays the most important problem preventing the app from working, hidden when there is none
+type statusMenu struct {
+	item *systray.MenuItem
+
+	mutex   sync.Mutex
+	current map[problem]bool
+	retry   map[problem]chan struct{} // retries the subsystem of a problem at once
+}
+
+func newStatusMenu() *statusMenu {
+	m := &statusMenu{
+		item:    systray.AddMenuItem("", ""),
+		current: make(map[problem]bool),
+		retry:   make(map[problem]chan struct{}, len(problems)),
+	}
+
+	for p := range problems {
+		m.retry[p] = make(chan struct{}, 1)
+	}
+
+	m.item.Hide()
+	return m
+}
+
+// Set displays p until it is cleared
+func (m *statusMenu) Set(p problem) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.current[p] = true
+	m.refresh()
+}
+
+// Clear stops displaying p
+func (m *statusMenu) Clear(p problem) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.current, p)
+	m.refresh()
+}
+
+// top returns the most important current problem
+func (m *statusMenu) top() (problem, bool) {
+	for p := problemNetwork; p <= problemPlayer; p++ {
+		if m.current[p] {
+			return p, true
+		}
+	}
+
+	return 0, false
+}
+
+// refresh displays the most important problem, m.mutex must be held
+func (m *statusMenu) refresh() {
+	p, ok := m.top()
+	if !ok {
+		m.item.Hide()
+		return
+	}
+
+	m.item.SetTitle(problems[p].title)
+	m.item.SetTooltip(problems[p].tooltip)
+	m.item.Show()
+}
+
+// HandleClicks opens the install page of the displayed problem, or retries its subsystem, until ctx is done
+func (m *statusMenu) HandleClicks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugln("received context cancel: HandleClicks")
+			return // returning not to leak the goroutine
+		case <-m.item.ClickedCh:
+		}
+
+		m.mutex.Lock()
+		p, ok := m.top()
+		m.mutex.Unlock()
+		if !ok {
+			continue
+		}
+
+		// installed tools are found on the next attempt
+		if u := problems[p].url; u != "" {
+			if err := browser.OpenURL(u); err != nil {
+				log.Errorf("unable to open install page: %s", err)
+			}
+		}
+
+		select {
+		case m.retry[p] <- struct{}{}:
+		default: // already requested
+		}
+	}
+}
+
+// attach calls try until it succeeds or ctx is done and returns whether it succeeded.
+// Each failure is displayed as the problem returned by try, then retried with backoff,
+// or only on click for problems needing the user.
+func (m *statusMenu) attach(ctx context.Context, name string, try func() (problem, error)) bool {
+	backoff := minAttachBackoff
+	for {
+		p, err := try()
+		if err == nil {
+			return true
+		}
+
+		m.Set(p)
+		wait := time.After(backoff)
+		if problems[p].manual {
+			log.Warningf("%s unavailable, waiting for the user: %s", name, err)
+			wait = nil // never fires
+		} else {
+			log.Warningf("%s unavailable, retrying in %s: %s", name, backoff, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-m.retry[p]:
+		case <-wait:
+			backoff *= 2
+			if backoff > maxAttachBackoff {
+				backoff = maxAttachBackoff
+			}
+		}
+
+		m.Clear(p)
+	}
+}
+
+// AttachMedia finds streamlink and a media player, waiting for them to be installed when missing
+func (a *Application) AttachMedia(ctx context.Context, status *statusMenu) {
+	go status.attach(ctx, "streamlink", func() (problem, error) {
+		s, err := streamlink.New()
+		if err == nil {
+			a.mediaMutex.Lock()
+			a.Streamlink = s
+			a.mediaMutex.Unlock()
+		}
+
+		return problemStreamlink, err
+	})
+
+	go status.attach(ctx, "media player", func() (problem, error) {
+		p, err := player.DefaultPlayer()
+		if err == nil {
+			a.mediaMutex.Lock()
+			a.Player = p
+			a.mediaMutex.Unlock()
+		}
+
+		return problemPlayer, err
+	})
+}
+
+// AttachTwitch connects to Twitch, waiting for the network or for the user to log in, then starts the application.
+func (a *Application) AttachTwitch(ctx context.Context, status *statusMenu) {
+	connected := status.attach(ctx, "Twitch", func() (problem, error) {
+		err := a.ConnectTwitch()
+		if isNetworkError(err) || errors.Is(err, twitch.ErrServer) {
+			return problemNetwork, err
+		}
+
+		return problemLogin, err
+	})
+
+	if connected {
+		a.Start(ctx)
+	}
+}
+
+// media returns streamlink and the media player, or an error while one is missing
+func (a *Application) media() (streamlink.Client, player.Player, error) {
+	a.mediaMutex.RLock()
+	defer a.mediaMutex.RUnlock()
+
+	switch {
+	case a.Streamlink == nil:
+		return nil, nil, streamlink.ErrStreamLinkNotFound
+	case a.Player == nil:
+		return nil, nil, errPlayerNotFound
+	}
+
+	return a.Streamlink, a.Player, nil
+}
+
+// errPlayerNotFound is returned when playing while no media player is installed
+var errPlayerNotFound = errors.New("cannot find any compatible media player")
+
+// isNetworkError returns whether err is caused by Twitch being unreachable or too slow to answer.
+// Login timeouts and TLS failures are not, retrying would not help.
+func isNetworkError(err error) bool {
+	if errors.Is(err, twitch.ErrLoginTimeout) || isTLSError(err) {
+		return false
+	}
+
+	var urlError *url.Error
+	var netError net.Error
+	return errors.As(err, &urlError) || errors.As(err, &netError) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isTLSError returns whether err is caused by a rejected certificate or a broken TLS handshake
+func isTLSError(err error) bool {
+	var (
+		verificationError *tls.CertificateVerificationError
+		recordHeaderError tls.RecordHeaderError
+		unknownAuthority  x509.UnknownAuthorityError
+		invalidError      x509.CertificateInvalidError
+		hostnameError     x509.HostnameError
+		systemRootsError  x509.SystemRootsError
+	)
+
+	return errors.As(err, &verificationError) ||
+		errors.As(err, &recordHeaderError) ||
+		errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalidError) ||
+		errors.As(err, &hostnameError) ||
+		errors.As(err, &systemRootsError)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/pkg/streamlink"
+)
+
+func Test_isNetworkError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Expected unreachable Twitch",
+			err:  fmt.Errorf("unable to initialize client: %w", &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: &net.DNSError{Err: "no such host"}}),
+			want: true,
+		},
+		{
+			name: "Expected refused connection",
+			err:  &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			want: true,
+		},
+		{
+			name: "Expected network error outside of a request",
+			err:  fmt.Errorf("unable to connect: %w", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}),
+			want: true,
+		},
+		{
+			name: "Expected login timeout not to be a network error",
+			err:  fmt.Errorf("%w: %w", twitch.ErrLoginTimeout, context.DeadlineExceeded),
+			want: false,
+		},
+		{
+			name: "Expected request deadline",
+			err:  fmt.Errorf("unable to initialize client: %w", &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: context.DeadlineExceeded}),
+			want: true,
+		},
+		{
+			name: "Expected client timeout",
+			err:  clientTimeout(t),
+			want: true,
+		},
+		{
+			name: "Expected unknown certificate authority not to be a network error",
+			err:  &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}},
+			want: false,
+		},
+		{
+			name: "Expected invalid hostname not to be a network error",
+			err:  &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: x509.HostnameError{Host: "api.twitch.tv"}},
+			want: false,
+		},
+		{
+			name: "Expected broken TLS handshake not to be a network error",
+			err:  &url.Error{Op: "Get", URL: "https://api.twitch.tv/helix/users", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}},
+			want: false,
+		},
+		{
+			name: "Expected plain error not to be a network error",
+			err:  errors.New("foo"),
+			want: false,
+		},
+		{
+			name: "Expected Twitch error not to be a network error",
+			err:  &twitch.Error{Status: 401},
+			want: false,
+		},
+		{
+			name: "Expected nil",
+			err:  nil,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNetworkError(tt.err); got != tt.want {
+				t.Errorf("isNetworkError() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// clientTimeout returns the error of a request exceeding http.Client.Timeout
+func clientTimeout(t *testing.T) error {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Timeout: time.Millisecond * 10}
+	_, err := client.Get(srv.URL)
+	if err == nil {
+		t.Fatalf("Get() error = nil, want timeout")
+	}
+
+	return err
+}
+
+func TestApplication_Play_missingMedia(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     *Application
+		wantErr error
+	}{
+		{
+			name:    "Expected streamlink not found",
+			app:     &Application{Player: &fakePlayer{}},
+			wantErr: streamlink.ErrStreamLinkNotFound,
+		},
+		{
+			name:    "Expected player not found",
+			app:     &Application{Streamlink: &fakeStreamlink{}},
+			wantErr: errPlayerNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.app.Play("https://www.twitch.tv/foo", "foo"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Play() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
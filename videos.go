@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/pkg/player"
+	"github.com/getlantern/systray"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	videosRefreshInterval = time.Minute * 30 // latest videos refresh interval
+	maxVideos             = 5                // number of videos listed per streamer
+	minPosition           = time.Second * 30 // videos stopped earlier are played from the beginning next time
+	finishedMargin        = time.Minute * 2  // videos stopped this close to their end are watched
+)
+
+// newVideosMenu lists the latest past broadcasts, highlights and uploads of a streamer
+func (a *Application) newVideosMenu(parent *systray.MenuItem) *mediaMenu[*twitch.Video] {
+	menu := parent.AddSubMenuItem("Latest VODs", "Latest past broadcasts, highlights and uploads")
+	return newMediaMenu(menu, "No VOD", a.describeVideo)
+}
+
+// describeVideo returns the displayed title and tooltip of given video
+func (a *Application) describeVideo(v *twitch.Video) (string, string) {
+	title := fmt.Sprintf("%s (%s, %s)", v.Title, formatPosition(v.Length()), v.CreatedAt.Local().Format("Jan 2"))
+	if v.Type != twitch.VideoArchive {
+		title = fmt.Sprintf("[%s] %s", videoTypeName(v.Type), title)
+	}
+
+	if position := a.resumePosition(v); position > 0 {
+		return title, "Resume at " + formatPosition(position)
+	}
+
+	return title, "Play " + strings.ToLower(videoTypeName(v.Type))
+}
+
+// videoTypeName returns the displayed name of given video type
+func videoTypeName(t twitch.VideoType) string {
+	switch t {
+	case twitch.VideoArchive:
+		return "Past broadcast"
+	case twitch.VideoHighlight:
+		return "Highlight"
+	default:
+		return "Upload"
+	}
+}
+
+// formatPosition formats d as h:mm:ss
+func formatPosition(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// RefreshVideos lists the latest videos every videosRefreshInterval, while the Item is visible
+func (i *Item) RefreshVideos(ctx context.Context) {
+	for {
+		if i.IsVisible() {
+			i.SetVideos(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugf("received context cancel: RefreshVideos [%s]", i.UserLogin)
+			return // returning not to leak the goroutine
+		case <-time.After(videosRefreshInterval):
+		}
+	}
+}
+
+// SetVideos lists the latest videos
+func (i *Item) SetVideos(ctx context.Context) {
+	videos, err := i.Application.LatestVideos(ctx, i.UserID)
+	if err != nil {
+		log.Errorf("unable to list videos of %s: %s", i.UserLogin, err)
+		return
+	}
+
+	i.videos.Set(videos, i.Application.videoPlayer(i.videos))
+}
+
+// LatestVideos returns the latest videos of any type of given user
+func (a *Application) LatestVideos(ctx context.Context, userID string) ([]*twitch.Video, error) {
+	return a.client().Videos.GetByUser(ctx, userID, twitch.VideoAll, maxVideos)
+}
+
+// videoPlayer returns a function playing the clicked video of menu
+func (a *Application) videoPlayer(menu *mediaMenu[*twitch.Video]) func(*twitch.Video) {
+	return func(v *twitch.Video) {
+		log.Debugf("[%s] video %s is clicked", v.UserLogin, v.ID)
+		if err := a.PlayVideo(v); err != nil {
+			log.Errorln(err)
+		}
+
+		menu.Refresh() // resume position changed
+	}
+}
+
+// PlayVideo opens given video in the player, where it was last stopped if configured.
+// Players reporting where they were stopped update the resume position once closed.
+func (a *Application) PlayVideo(v *twitch.Video) error {
+	start := a.resumePosition(v)
+	return a.play(v.URL, func(p player.Player, u string, output io.Writer) error {
+		log.Debugf("starting [%s] at %s", v.URL, formatPosition(start))
+		switch p := p.(type) {
+		case player.Resumer:
+			stopped, err := p.RunResume(u, v.Title, start, output)
+			if err == nil {
+				a.savePosition(v, stopped)
+			}
+
+			return err
+		case player.Seeker:
+			return p.RunAt(u, v.Title, start, output)
+		default:
+			return p.Run(u, v.Title, output)
+		}
+	})
+}
+
+// resumePosition returns where given video should start, zero unless resuming videos
+func (a *Application) resumePosition(v *twitch.Video) time.Duration {
+	if !a.config.ResumeVideos || a.positions == nil {
+		return 0
+	}
+
+	return a.positions.Get(v.ID)
+}
+
+// savePosition remembers where given video was stopped, as reported by the player.
+// Videos stopped near their start or end are forgotten.
+func (a *Application) savePosition(v *twitch.Video, stopped time.Duration) {
+	if !a.config.ResumeVideos || a.positions == nil {
+		return
+	}
+
+	if length := v.Length(); stopped < minPosition || (length > 0 && stopped > length-finishedMargin) {
+		a.positions.Delete(v.ID)
+	} else {
+		a.positions.Set(v.ID, stopped)
+	}
+
+	if err := a.positions.Save(); err != nil {
+		log.Errorln(err)
+	}
+}
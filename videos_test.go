@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SkYNewZ/twitch-clip/internal/config"
+	"github.com/SkYNewZ/twitch-clip/internal/twitch"
+	"github.com/SkYNewZ/twitch-clip/pkg/player"
+)
+
+func Test_formatPosition(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{name: "Expected zero", d: 0, want: "0:00:00"},
+		{name: "Expected minutes", d: time.Minute*4 + time.Second*5, want: "0:04:05"},
+		{name: "Expected hours", d: time.Hour*3 + time.Minute*8 + time.Millisecond*33600, want: "3:08:34"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatPosition(tt.d); got != tt.want {
+				t.Errorf("formatPosition() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeResumer is a player reporting it was stopped at stopped
+type fakeResumer struct {
+	fakePlayer
+	stopped time.Duration
+}
+
+func (p *fakeResumer) RunResume(u, title string, start time.Duration, output io.Writer) (time.Duration, error) {
+	return p.stopped, p.RunAt(u, title, start, output)
+}
+
+func TestApplication_PlayVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		resume  bool
+		stopped time.Duration // reported by the player, -1 if it cannot
+		want    time.Duration
+		saved   time.Duration // resume position once played
+	}{
+		{name: "Expected resumed", resume: true, stopped: -1, want: time.Minute * 10, saved: time.Minute * 10},
+		{name: "Expected from the beginning", resume: false, stopped: -1, want: 0, saved: time.Minute * 10},
+		{name: "Expected reported position saved", resume: true, stopped: time.Minute * 25, want: time.Minute * 10, saved: time.Minute * 25},
+		{name: "Expected forgotten when played until the end", resume: true, stopped: 0, want: time.Minute * 10, saved: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p player.Player
+			fake := &fakeResumer{stopped: tt.stopped}
+			if tt.stopped < 0 {
+				p = &fake.fakePlayer
+			} else {
+				p = fake
+			}
+
+			a := &Application{
+				Streamlink:        &fakeStreamlink{},
+				Player:            p,
+				ClipboardListener: make(chan string, 1),
+				positions:         &config.Positions{},
+				config:            &config.Config{ResumeVideos: tt.resume},
+			}
+			a.positions.Set("v1", time.Minute*10)
+
+			v := &twitch.Video{ID: "v1", URL: "https://www.twitch.tv/videos/v1", Duration: "1h"}
+			if err := a.PlayVideo(v); err != nil {
+				t.Fatalf("PlayVideo() error = %v", err)
+			}
+			if want := []string{"https://www.twitch.tv/videos/v1/stream"}; !reflect.DeepEqual(fake.played, want) {
+				t.Errorf("PlayVideo() played = %v, want %v", fake.played, want)
+			}
+			if want := []time.Duration{tt.want}; !reflect.DeepEqual(fake.starts, want) {
+				t.Errorf("PlayVideo() starts = %v, want %v", fake.starts, want)
+			}
+			if got := a.positions.Get("v1"); got != tt.saved {
+				t.Errorf("PlayVideo() saved = %v, want %v", got, tt.saved)
+			}
+		})
+	}
+}
+
+func TestApplication_savePosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		stopped time.Duration
+		want    time.Duration
+	}{
+		{name: "Expected saved", stopped: time.Minute * 15, want: time.Minute * 15},
+		{name: "Expected forgotten when stopped at the beginning", stopped: time.Second, want: 0},
+		{name: "Expected forgotten when finished", stopped: time.Minute * 59, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Application{
+				positions: &config.Positions{},
+				config:    &config.Config{ResumeVideos: true},
+			}
+			a.positions.Set("v1", time.Minute*10)
+
+			a.savePosition(&twitch.Video{ID: "v1", Duration: "1h"}, tt.stopped)
+			if got := a.positions.Get("v1"); got != tt.want {
+				t.Errorf("savePosition() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}